@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/github"
+	"github.com/khulnasoft/packages-registry/jenkins"
 	"github.com/khulnasoft/packages-registry/khulnasoft"
 	"github.com/khulnasoft/packages-registry/logger"
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/shell"
+	"github.com/khulnasoft/packages-registry/tekton"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +23,19 @@ var generateCmd = &cobra.Command{
 	Short: "Generates the pipeline config path.",
 	Long: `Generates the pipeline config path.
 
-	Use the pipeline_config flag to specify a file, otherwise "child_pipeline.yml" is used`,
+	Use the target flag to choose the CI/CD system:
+	- khulnasoft (default): a KhulnaSoft child pipeline
+	- github: a GitHub Actions workflow
+	- shell: a shell script that imports the packages from the host it runs on
+	- jenkins: a declarative Jenkinsfile
+	- tekton: Tekton Task and Pipeline resources
+
+	Use the matrix flag with the khulnasoft target to get a job with a parallel matrix per import
+	instead of a job per package version.
+
+	Use the pipeline_config flag to specify a file, otherwise "child_pipeline.yml" is used
+	for khulnasoft, "import_packages.yml" for github, "import_packages.sh" for shell,
+	"Jenkinsfile" for jenkins and "import_packages.yaml" for tekton`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if errorReadingConfig {
 			return
@@ -28,14 +47,25 @@ var generateCmd = &cobra.Command{
 			return
 		}
 
-		outputFile, err := outputFile()
+		backend, err := getBackend(target, matrix)
+		if err != nil {
+			logger.LogError("Error while choosing the target:", err)
+			return
+		}
+
+		path := pipelineConfigFilePath
+		if !cmd.Flags().Changed("pipeline_config") {
+			path = backend.DefaultFileName()
+		}
+
+		outputFile, err := outputFile(path)
 		if err != nil {
 			logger.LogError("Error while opening the output file:", err)
 			return
 		}
 
-		generator := khulnasoft.NewGenerator(configuration)
-		if err = generator.Generate(outputFile); err != nil {
+		generator := khulnasoft.NewGenerator(configuration, transfer.NewClient(nil), backend)
+		if err = generator.Generate(context.Background(), outputFile); err != nil {
 			logger.LogError("Error while generating the engine config:", err)
 			return
 		}
@@ -44,12 +74,50 @@ var generateCmd = &cobra.Command{
 	},
 }
 
+const (
+	targetKhulnaSoft = "khulnasoft"
+	targetGithub     = "github"
+	targetShell      = "shell"
+	targetJenkins    = "jenkins"
+	targetTekton     = "tekton"
+	defaultTarget    = targetKhulnaSoft
+)
+
+var (
+	target string
+	matrix bool
+)
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringVar(&target, "target", defaultTarget, "CI/CD system of the pipeline config: khulnasoft, github, shell, jenkins or tekton")
+	generateCmd.Flags().BoolVar(&matrix, "matrix", false, "Use a job with a parallel matrix per import instead of a job per package, khulnasoft target only")
+}
+
+func getBackend(target string, matrix bool) (pipeline.Backend, error) {
+	if matrix && target != targetKhulnaSoft {
+		return nil, fmt.Errorf("the matrix flag is not supported by the %q target", target)
+	}
+
+	switch target {
+	case targetKhulnaSoft:
+		return khulnasoft.Backend{Matrix: matrix}, nil
+	case targetGithub:
+		return github.Backend{}, nil
+	case targetShell:
+		return shell.Backend{}, nil
+	case targetJenkins:
+		return jenkins.Backend{}, nil
+	case targetTekton:
+		return tekton.Backend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown target %q", target)
+	}
 }
 
-func outputFile() (*os.File, error) {
-	f, err := os.Create(pipelineConfigFilePath)
+func outputFile(path string) (*os.File, error) {
+	f, err := os.Create(path)
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/khulnasoft/packages-registry/github"
+	"github.com/khulnasoft/packages-registry/jenkins"
+	"github.com/khulnasoft/packages-registry/shell"
+	"github.com/khulnasoft/packages-registry/tekton"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
@@ -49,6 +53,36 @@ func TestGenerate(t *testing.T) {
 			args:            []string{"generate", "-c", "../testdata/single_import.yml", "-p", testdataPipelineConfigPath},
 			expectedOutputs: []string{"Config loaded", `Writing pipeline config file "../testdata/output/pipeline_config.yml"`, "Pipeline config generated!"},
 		},
+		{
+			name:            "github target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "github"},
+			expectedOutputs: []string{"Config loaded", `Writing pipeline config file "import_packages.yml"`, "Pipeline config generated!"},
+		},
+		{
+			name:            "shell target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "shell"},
+			expectedOutputs: []string{"Config loaded", `Writing pipeline config file "import_packages.sh"`, "Pipeline config generated!"},
+		},
+		{
+			name:            "jenkins target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "jenkins"},
+			expectedOutputs: []string{"Config loaded", `Writing pipeline config file "Jenkinsfile"`, "Pipeline config generated!"},
+		},
+		{
+			name:            "tekton target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "tekton"},
+			expectedOutputs: []string{"Config loaded", `Writing pipeline config file "import_packages.yaml"`, "Pipeline config generated!"},
+		},
+		{
+			name:            "unknown target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "unknown"},
+			expectedOutputs: []string{"Error while choosing the target:", `unknown target "unknown"`},
+		},
+		{
+			name:            "matrix with another target",
+			args:            []string{"generate", "-c", "../testdata/single_import.yml", "--target", "github", "--matrix"},
+			expectedOutputs: []string{"Error while choosing the target:", `the matrix flag is not supported by the "github" target`},
+		},
 		{
 			name:            "unknown type",
 			args:            []string{"generate", "-c", "../testdata/unknown_type.yml"},
@@ -96,32 +130,98 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
-const testdataExpectedExactMatchPipelineConfigPath = "../testdata/expected_exact_match_pipeline_config.yml"
-
 func TestGenerateExactMatch(t *testing.T) {
-	args := []string{"generate", "-c", "../testdata/exact_match.yml", "-p", testdataPipelineConfigPath}
+	tests := []struct {
+		name         string
+		configPath   string
+		target       string
+		matrix       bool
+		expectedPath string
+	}{
+		{
+			name:         "all formats",
+			configPath:   "../testdata/exact_match.yml",
+			expectedPath: "../testdata/expected_exact_match_pipeline_config.yml",
+		},
+		{
+			name:         "helm",
+			configPath:   "../testdata/helm.yml",
+			expectedPath: "../testdata/expected_helm_pipeline_config.yml",
+		},
+		{
+			name:         "matrix",
+			configPath:   "../testdata/exact_match.yml",
+			matrix:       true,
+			expectedPath: "../testdata/expected_exact_match_matrix_pipeline_config.yml",
+		},
+		{
+			name:         "github target",
+			configPath:   "../testdata/exact_match.yml",
+			target:       "github",
+			expectedPath: "../testdata/expected_exact_match_github_workflow.yml",
+		},
+		{
+			name:         "shell target",
+			configPath:   "../testdata/exact_match.yml",
+			target:       "shell",
+			expectedPath: "../testdata/expected_exact_match_shell_script.sh",
+		},
+		{
+			name:         "jenkins target",
+			configPath:   "../testdata/exact_match.yml",
+			target:       "jenkins",
+			expectedPath: "../testdata/expected_exact_match_jenkinsfile",
+		},
+		{
+			name:         "tekton target",
+			configPath:   "../testdata/exact_match.yml",
+			target:       "tekton",
+			expectedPath: "../testdata/expected_exact_match_tekton_resources.yaml",
+		},
+	}
 
-	t.Cleanup(reset)
-	log.SetOutput(io.Discard)
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			args := []string{"generate", "-c", spec.configPath, "-p", testdataPipelineConfigPath}
+			if len(spec.target) != 0 {
+				args = append(args, "--target", spec.target)
+			}
+			if spec.matrix {
+				args = append(args, "--matrix")
+			}
 
-	rootCmd.SetArgs(args)
+			t.Cleanup(reset)
+			log.SetOutput(io.Discard)
 
-	err := rootCmd.Execute()
-	require.Nil(t, err)
+			rootCmd.SetArgs(args)
+
+			err := rootCmd.Execute()
+			require.Nil(t, err)
 
-	result, err := os.ReadFile(testdataPipelineConfigPath)
-	require.Nil(t, err)
+			result, err := os.ReadFile(testdataPipelineConfigPath)
+			require.Nil(t, err)
 
-	expected, err := os.ReadFile(testdataExpectedExactMatchPipelineConfigPath)
-	require.Nil(t, err)
+			expected, err := os.ReadFile(spec.expectedPath)
+			require.Nil(t, err)
 
-	require.Equal(t, string(expected), string(result))
+			require.Equal(t, string(expected), string(result))
+		})
+	}
 }
 
 func reset() {
 	viper.Reset()
 	errorReadingConfig = false
+	workers = defaultWorkers
+	target = defaultTarget
+	matrix = false
+	pipelineConfigFilePath = defaultPipelineConfigFilePath
+	rootCmd.PersistentFlags().Lookup("pipeline_config").Changed = false
 	os.Remove(testdataPipelineConfigPath)
 	log.SetOutput(os.Stderr)
 	os.Remove(defaultPipelineConfigFilePath)
+	os.Remove(github.Backend{}.DefaultFileName())
+	os.Remove(shell.Backend{}.DefaultFileName())
+	os.Remove(jenkins.Backend{}.DefaultFileName())
+	os.Remove(tekton.Backend{}.DefaultFileName())
 }
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/importer"
+	"github.com/khulnasoft/packages-registry/logger"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/spf13/cobra"
+)
+
+var workers int
+
+const defaultWorkers = 4
+
+// errImportFailed is returned by the import command once the failure is logged, for the process to
+// exit with a non-zero status.
+var errImportFailed = errors.New("the import failed")
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports the packages directly.",
+	Long: `Imports the packages directly.
+
+	The packages are downloaded from the source registries and uploaded to the destination registries
+	from this process, without a pipeline, docker images or package manager CLIs.
+	Only the npm, nuget, maven, pypi, golang and generic types are supported.
+
+	Use the workers flag to set the number of packages imported at the same time, otherwise 4 is used.
+	On an interruption, the ongoing imports are canceled and the imported packages are listed.
+	The exit status is not 0 when a package failed to import or the import was interrupted`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if errorReadingConfig {
+			return errImportFailed
+		}
+
+		if workers < 1 {
+			logger.LogError("Error while reading the flags:", fmt.Errorf("workers must be at least 1, got %d", workers))
+			return errImportFailed
+		}
+
+		configuration, err := config.Load()
+		if err != nil {
+			logger.LogError("Error while loading the config:", err)
+			return errImportFailed
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		packagesImporter := importer.NewImporter(configuration, transfer.NewClient(nil), workers)
+		report, err := packagesImporter.Import(ctx)
+		if errors.Is(err, context.Canceled) {
+			logger.LogError("Import interrupted:", err)
+			logReport(report)
+			return errImportFailed
+		}
+		if err != nil {
+			logger.LogError("Error while importing the packages:", err)
+			return errImportFailed
+		}
+
+		logger.LogSuccess("Packages imported!")
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().IntVarP(&workers, "workers", "w", defaultWorkers, "Number of packages imported at the same time")
+	rootCmd.AddCommand(importCmd)
+}
+
+func logReport(report *importer.Report) {
+	logger.LogInfo(fmt.Sprintf("%d packages imported, %d skipped, %d failed and %d not started", len(report.Imported), len(report.Skipped), len(report.Failed), report.NotStarted))
+
+	for _, pkg := range report.Imported {
+		logger.LogInfo(fmt.Sprintf("Completed: %s", pkg))
+	}
+}
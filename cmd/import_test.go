@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const importConfigTemplate = `import1:
+  type: generic
+  source:
+    url: %s
+  destination:
+    url: %s
+    credentials:
+      token: 1234567890
+      username: user
+  packages:
+    "first": 1.3.7:file.txt
+    "second": 2.0.0:file.txt
+`
+
+const resolutionConfigTemplate = `import1:
+  type: npm
+  source:
+    url: %s
+  destination:
+    url: %s
+    credentials:
+      token: 1234567890
+  packages:
+    "second": latest:1
+`
+
+func TestImport(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/missing/") {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/interrupted/second/") || r.URL.Path == "/resolution/second" {
+			process, err := os.FindProcess(os.Getpid())
+			require.NoError(t, err)
+			require.NoError(t, process.Signal(os.Interrupt))
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	t.Cleanup(source.Close)
+
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(destination.Close)
+
+	configPath := writeImportConfig(t, "config.yml", source.URL, destination.URL)
+	missingSourceConfigPath := writeImportConfig(t, "missing_source.yml", source.URL+"/missing", destination.URL)
+	interruptedConfigPath := writeImportConfig(t, "interrupted.yml", source.URL+"/interrupted", destination.URL)
+	resolutionInterruptedConfigPath := filepath.Join(t.TempDir(), "resolution_interrupted.yml")
+	require.NoError(t, os.WriteFile(resolutionInterruptedConfigPath, []byte(fmt.Sprintf(resolutionConfigTemplate, source.URL+"/resolution", destination.URL)), 0o644))
+
+	tests := []struct {
+		name            string
+		args            []string
+		expectedOutputs []string
+		expectedError   error
+	}{
+		{
+			name:            "non existing config file",
+			args:            []string{"import", "-c", "does_not_exist.yml"},
+			expectedOutputs: []string{"Error while reading the config:", "open does_not_exist.yml: no such file or directory"},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "unknown type",
+			args:            []string{"import", "-c", "../testdata/unknown_type.yml"},
+			expectedOutputs: []string{"'Configuration.Imports[import1].Type' Error:Field validation"},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "unsupported type",
+			args:            []string{"import", "-c", "../testdata/helm.yml"},
+			expectedOutputs: []string{"Error while importing the packages:", `the "helm" type of import "import1" doesn't support in-process imports yet`},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "invalid workers",
+			args:            []string{"import", "-c", "../testdata/single_import.yml", "-w", "0"},
+			expectedOutputs: []string{"Error while reading the flags:", "workers must be at least 1, got 0"},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "packages imported",
+			args:            []string{"import", "-c", configPath},
+			expectedOutputs: []string{"Config loaded", `Imported first 1.3.7:file.txt in import "import1"`, `Imported second 2.0.0:file.txt in import "import1"`, "Packages imported!"},
+		},
+		{
+			name:            "packages not found",
+			args:            []string{"import", "-c", missingSourceConfigPath},
+			expectedOutputs: []string{`Error while importing first 1.3.7:file.txt in import "import1"`, "Error while importing the packages:", "2 of 2 packages failed to import"},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "interrupted",
+			args:            []string{"import", "-c", interruptedConfigPath, "-w", "1"},
+			expectedOutputs: []string{"Import interrupted:", "context canceled", "1 packages imported, 0 skipped, 1 failed and 0 not started", `Completed: first 1.3.7:file.txt in import "import1"`},
+			expectedError:   errImportFailed,
+		},
+		{
+			name:            "interrupted during the resolution",
+			args:            []string{"import", "-c", resolutionInterruptedConfigPath},
+			expectedOutputs: []string{"Import interrupted:", "can't list the versions of second", "0 packages imported, 0 skipped, 0 failed and 0 not started"},
+			expectedError:   errImportFailed,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			buff := new(strings.Builder)
+
+			t.Cleanup(reset)
+			log.SetOutput(buff)
+
+			rootCmd.SetArgs(spec.args)
+			err := rootCmd.Execute()
+			require.Equal(t, spec.expectedError, err)
+
+			output := buff.String()
+
+			for _, expectedOutput := range spec.expectedOutputs {
+				require.Contains(t, output, expectedOutput)
+			}
+		})
+	}
+}
+
+func writeImportConfig(t *testing.T, name, sourceUrl, destinationUrl string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(importConfigTemplate, sourceUrl, destinationUrl)), 0o644))
+
+	return path
+}
@@ -1,5 +1,5 @@
 // Package cmd host all the commands available and follows the [cobra](https://github.com/spf13/cobra) skeleton.
-// Only one command is available: generate. The root command hosts the pieces that can be shared between available commands.
+// Two commands are available: generate and import. The root command hosts the pieces that can be shared between available commands.
 package cmd
 
 import (
@@ -28,18 +28,33 @@ two packages regisitries. A import configuration will contain the credentials to
 registry and a description of the packages to copy.
 
 From this, this tool will configure a KhulnaSoft dynamic child pipeline that will carry out the copy.
+The copy can also be carried out directly by this tool with the import command.
 
 Supported package types:
 - npm
 - maven
 - nuget
-- pypi`,
+- pypi
+- golang
+- rubygems
+- composer
+- helm
+- cargo
+- generic
+- conan
+- docker (or oci)
+- terraform
+- debian
+- rpm
+- conda
+- pub
+- hex`,
 	Version: fmt.Sprintf("%q, build time %q, commit %q", version, buildTime, commit),
 }
 
 const defaultPipelineConfigFilePath = "child_pipeline.yml"
 
-// Execute will execute the command. Depending on the arguments, the generate command is executed or the help message is displayed.
+// Execute will execute the command. Depending on the arguments, the generate or import command is executed or the help message is displayed.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
@@ -27,6 +27,23 @@ func TestLoad(t *testing.T) {
 			configFixture: "multiple_imports.yml",
 			expectError:   false,
 		},
+		{
+			name:          "with max concurrency",
+			configFixture: "max_concurrency.yml",
+			expectError:   false,
+		},
+		{
+			name:                 "with invalid max concurrency",
+			configFixture:        "invalid_max_concurrency.yml",
+			expectError:          true,
+			expectedErrorMessage: "Key: 'Configuration.Imports[import1].Source.MaxConcurrency' Error:Field validation for 'MaxConcurrency' failed on the 'min' tag",
+		},
+		{
+			name:                 "with invalid on existing",
+			configFixture:        "invalid_on_existing.yml",
+			expectError:          true,
+			expectedErrorMessage: "Key: 'Configuration.Imports[import1].OnExisting' Error:Field validation for 'OnExisting' failed on the 'oneof' tag",
+		},
 		{
 			name:                 "with same urls",
 			configFixture:        "same_urls.yml",
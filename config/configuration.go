@@ -21,8 +21,9 @@ func (c *Credentials) UseBase64Token() bool {
 
 // Represents a package registry.
 type Registry struct {
-	URL         string      `validate:"required,url"` // the url where the registry is located. Required.
-	Credentials Credentials // the credentials to be used. Optionnal.
+	URL            string      `validate:"required,url"` // the url where the registry is located. Required.
+	Credentials    Credentials // the credentials to be used. Optionnal.
+	MaxConcurrency int         `mapstructure:"max_concurrency" validate:"omitempty,min=1"` // the maximum number of packages imported at the same time from or to this registry by the import command. Optionnal, unlimited by default.
 }
 
 func (r *Registry) requireCredentialsToken(registryLabel string, importName string) error {
@@ -35,10 +36,29 @@ func (r *Registry) requireCredentialsToken(registryLabel string, importName stri
 // Represents an import operation to carry. It's mainly caracterized by a source and a destination
 // registry.
 type Import struct {
-	Type        string   `validate:"required,oneof=npm nuget maven pypi"` // The import type. Only npm, nuget, maven and pypi are valid values.
+	Type        string   `validate:"required,oneof=npm nuget maven pypi golang rubygems composer helm cargo generic conan docker oci terraform debian rpm conda pub hex"` // The import type. Only npm, nuget, maven, pypi, golang, rubygems, composer, helm, cargo, generic, conan, docker, oci, terraform, debian, rpm, conda, pub and hex are valid values.
 	Image       string   // The image to use for the jobs that execute this import. Optionnal.
-	Source      Registry `validate:"required"` // The source registry. Required.
-	Destination Registry `validate:"required"` // The destination registry. Required.
+	Source      Registry `validate:"required"`                                                       // The source registry. Required.
+	Destination Registry `validate:"required"`                                                       // The destination registry. Required.
+	OnExisting  string   `mapstructure:"on_existing" validate:"omitempty,oneof=skip fail overwrite"` // What to do with the packages already in the destination registry. Optionnal, overwrite by default.
+	// Whether the dependencies of the packages, and their own dependencies, are imported too. Optionnal, false by default.
+	IncludeDependencies bool `mapstructure:"include_dependencies"`
+	// The PEM encoded public key that verifies the signed resources of the source repository. Only used by the hex type. Optionnal, the hex.pm key by default.
+	PublicKey string `mapstructure:"public_key"`
+}
+
+// The values of Import.OnExisting. With overwrite, the destination registry is not checked and the
+// packages are always imported. Some registries reject the packages they already have.
+const (
+	OnExistingSkip      = "skip"
+	OnExistingFail      = "fail"
+	OnExistingOverwrite = "overwrite"
+)
+
+// ChecksExisting returns true when the packages already in the destination registry must be
+// looked up before being imported.
+func (i *Import) ChecksExisting() bool {
+	return i.OnExisting == OnExistingSkip || i.OnExisting == OnExistingFail
 }
 
 func (i *Import) validate(importName string) error {
@@ -118,3 +118,23 @@ func TestImportValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestImportChecksExisting(t *testing.T) {
+	tests := []struct {
+		onExisting string
+		expected   bool
+	}{
+		{onExisting: "", expected: false},
+		{onExisting: OnExistingOverwrite, expected: false},
+		{onExisting: OnExistingSkip, expected: true},
+		{onExisting: OnExistingFail, expected: true},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.onExisting, func(t *testing.T) {
+			i := Import{OnExisting: spec.onExisting}
+
+			require.Equal(t, spec.expected, i.ChecksExisting())
+		})
+	}
+}
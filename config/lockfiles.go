@@ -0,0 +1,340 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// lockfileParser reads the package versions locked in a lockfile.
+type lockfileParser func(content []byte) (map[string][]string, error)
+
+// getLockfileParser returns the parser of a lockfile path, detected by its file name. It returns
+// false for a file that isn't a known lockfile.
+func getLockfileParser(lockfilePath string) (lockfileParser, bool) {
+	fileName := filepath.Base(lockfilePath)
+
+	switch {
+	case fileName == "package-lock.json" || fileName == "npm-shrinkwrap.json":
+		return parsePackageLock, true
+	case fileName == "yarn.lock":
+		return parseYarnLock, true
+	case fileName == "pnpm-lock.yaml":
+		return parsePnpmLock, true
+	case fileName == "poetry.lock":
+		return parsePoetryLock, true
+	case strings.HasPrefix(fileName, "requirements") && strings.HasSuffix(fileName, ".txt"):
+		return parseRequirements, true
+	case fileName == "packages.lock.json":
+		return parseNugetLock, true
+	case strings.HasSuffix(fileName, ".lockfile"):
+		return parseGradleLockfile, true
+	}
+
+	return nil, false
+}
+
+func getPackagesMapFromLockfile(lockfilePath string, parse lockfileParser) (map[string][]string, error) {
+	content, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lockfile %q: %w", lockfilePath, err)
+	}
+
+	// Most lockfiles are maps, sorting the versions gives a stable order.
+	for _, versions := range packages {
+		sort.Strings(versions)
+	}
+
+	return packages, nil
+}
+
+func addPackage(packages map[string][]string, name, version string) {
+	if !slices.Contains(packages[name], version) {
+		packages[name] = append(packages[name], version)
+	}
+}
+
+// parsePackageLock reads the packages section of the lockfile versions 2 and 3, or the nested
+// dependencies of the version 1. The linked and bundled packages are not in the registry.
+func parsePackageLock(content []byte) (map[string][]string, error) {
+	type entry struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		Link     bool   `json:"link"`
+		Bundled  bool   `json:"bundled"`
+		InBundle bool   `json:"inBundle"`
+	}
+	type dependency struct {
+		entry
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	var lockfile struct {
+		Packages     map[string]entry      `json:"packages"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lockfile); err != nil {
+		return nil, err
+	}
+
+	packages := map[string][]string{}
+	isRegistryPackage := func(e entry) bool {
+		return len(e.Version) != 0 && !e.Link && !e.Bundled && !e.InBundle && !strings.Contains(e.Version, ":")
+	}
+
+	if len(lockfile.Packages) != 0 {
+		for path, d := range lockfile.Packages {
+			index := strings.LastIndex(path, "node_modules/")
+			if index == -1 || !isRegistryPackage(d) {
+				continue
+			}
+
+			// An aliased package has the name of the alias in its path and its real name in name.
+			name := path[index+len("node_modules/"):]
+			if len(d.Name) != 0 {
+				name = d.Name
+			}
+			addPackage(packages, name, d.Version)
+		}
+		return packages, nil
+	}
+
+	var walk func(dependencies map[string]dependency)
+	walk = func(dependencies map[string]dependency) {
+		for name, d := range dependencies {
+			if isRegistryPackage(d.entry) {
+				addPackage(packages, name, d.Version)
+			}
+			walk(d.Dependencies)
+		}
+	}
+	walk(lockfile.Dependencies)
+
+	return packages, nil
+}
+
+var yarnVersionRegexp = regexp.MustCompile(`^\s+version:?\s+"?([^"\s]+)"?\s*$`)
+
+// parseYarnLock reads the entries of the classic and the berry lockfiles. An entry starts with its
+// unindented descriptors, like "lodash@^4.17.0, lodash@^4.17.21":, and has an indented version.
+// The entries that aren't resolved from the registry, like workspaces and patches, are ignored.
+func parseYarnLock(content []byte) (map[string][]string, error) {
+	packages := map[string][]string{}
+	name := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if len(line) != 0 && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") {
+			descriptor, _, _ := strings.Cut(strings.TrimSuffix(line, ":"), ",")
+			descriptor = strings.Trim(strings.TrimSpace(descriptor), `"`)
+			name = yarnDescriptorName(descriptor)
+			continue
+		}
+
+		if match := yarnVersionRegexp.FindStringSubmatch(line); match != nil && len(name) != 0 {
+			addPackage(packages, name, match[1])
+			name = ""
+		}
+	}
+
+	return packages, scanner.Err()
+}
+
+// yarnDescriptorName returns the package name of a descriptor, like @scope/name@^1.0.0 or
+// name@npm:^1.0.0, or an empty string when it's not resolved from the registry.
+func yarnDescriptorName(descriptor string) string {
+	index := versionSeparatorIndex(descriptor)
+	if index == -1 {
+		return ""
+	}
+
+	name, versionRange := descriptor[:index], descriptor[index+1:]
+	if protocol, _, ok := strings.Cut(versionRange, ":"); ok && protocol != "npm" {
+		return ""
+	}
+
+	return name
+}
+
+// versionSeparatorIndex returns the index of the @ between a package name and its version. The @ of
+// a scope, the first character, is skipped. It returns -1 without a version.
+func versionSeparatorIndex(key string) int {
+	if len(key) == 0 {
+		return -1
+	}
+
+	index := strings.Index(key[1:], "@")
+	if index == -1 {
+		return -1
+	}
+
+	return index + 1
+}
+
+// parsePnpmLock reads the keys of the packages section: /name/1.2.3 in the lockfile version 5,
+// /name@1.2.3 in the version 6 and name@1.2.3 in the version 9. The peer dependencies suffixes, like
+// (react@18.2.0) or _react@18.2.0, are removed. The packages resolved from a tarball, a git repository
+// or a directory are ignored.
+func parsePnpmLock(content []byte) (map[string][]string, error) {
+	var lockfile struct {
+		LockfileVersion interface{} `yaml:"lockfileVersion"`
+		Packages        map[string]struct {
+			Resolution map[string]interface{} `yaml:"resolution"`
+		} `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(content, &lockfile); err != nil {
+		return nil, err
+	}
+
+	slashSeparated := strings.HasPrefix(fmt.Sprint(lockfile.LockfileVersion), "5")
+	packages := map[string][]string{}
+
+	for key, p := range lockfile.Packages {
+		_, hasTarball := p.Resolution["tarball"]
+		_, hasType := p.Resolution["type"]
+		if hasTarball || hasType {
+			continue
+		}
+
+		key, _, _ = strings.Cut(strings.TrimPrefix(key, "/"), "(")
+
+		index := versionSeparatorIndex(key)
+		if slashSeparated {
+			// The peer dependencies don't contain a slash, they are escaped with a +. Package names,
+			// like string_decoder, can contain an underscore: only the version is cut.
+			index = strings.LastIndex(key, "/")
+		}
+		if index <= 0 {
+			continue
+		}
+
+		version := key[index+1:]
+		if slashSeparated {
+			version, _, _ = strings.Cut(version, "_")
+		}
+
+		addPackage(packages, key[:index], version)
+	}
+
+	return packages, nil
+}
+
+// parsePoetryLock reads the [[package]] tables. The packages from a directory, a file, a url or
+// a git repository are ignored.
+func parsePoetryLock(content []byte) (map[string][]string, error) {
+	var lockfile struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  struct {
+				Type string `toml:"type"`
+			} `toml:"source"`
+		} `toml:"package"`
+	}
+	if err := toml.Unmarshal(content, &lockfile); err != nil {
+		return nil, err
+	}
+
+	packages := map[string][]string{}
+	for _, p := range lockfile.Package {
+		if len(p.Source.Type) == 0 || p.Source.Type == "legacy" {
+			addPackage(packages, p.Name, p.Version)
+		}
+	}
+
+	return packages, nil
+}
+
+var requirementRegexp = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*===?\s*([^\s;\\]+)`)
+
+// parseRequirements reads the pinned requirements, like requests==2.31.0. The options, like
+// --hash or -r, and the comments are ignored. A requirement without a pin is an error.
+func parseRequirements(content []byte) (map[string][]string, error) {
+	packages := map[string][]string{}
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lineNumber++
+		line, _, _ := strings.Cut(scanner.Text(), " #")
+		line = strings.TrimSpace(line)
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		match := requirementRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("requirement %q on line %d is not pinned with ==", line, lineNumber)
+		}
+		addPackage(packages, match[1], match[2])
+	}
+
+	return packages, scanner.Err()
+}
+
+// parseNugetLock reads the resolved versions of all the target frameworks. The project references
+// are not in the registry.
+func parseNugetLock(content []byte) (map[string][]string, error) {
+	var lockfile struct {
+		Dependencies map[string]map[string]struct {
+			Type     string `json:"type"`
+			Resolved string `json:"resolved"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lockfile); err != nil {
+		return nil, err
+	}
+
+	packages := map[string][]string{}
+	for _, dependencies := range lockfile.Dependencies {
+		for name, d := range dependencies {
+			if d.Type != "Project" && len(d.Resolved) != 0 {
+				addPackage(packages, name, d.Resolved)
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+// parseGradleLockfile reads the group:artifact:version=configurations lines of a Gradle lockfile.
+func parseGradleLockfile(content []byte) (map[string][]string, error) {
+	packages := map[string][]string{}
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "empty=") {
+			continue
+		}
+
+		coordinates, _, _ := strings.Cut(line, "=")
+		parts := strings.Split(coordinates, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("line %d: expected group:artifact:version, got %q", lineNumber, coordinates)
+		}
+		addPackage(packages, parts[0]+":"+parts[1], parts[2])
+	}
+
+	return packages, scanner.Err()
+}
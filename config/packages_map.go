@@ -38,14 +38,20 @@ func getPackagesMapValue(name string) (map[string][]string, error) {
 		stringValue := viper.GetString(key)
 		if strings.HasSuffix(stringValue, ".csv") {
 			return getPackagesMapFromCSV(stringValue)
+		} else if parse, ok := getLockfileParser(stringValue); ok {
+			return getPackagesMapFromLockfile(stringValue, parse)
 		} else {
-			return nil, fmt.Errorf("packages of import %q (value %q) is not a csv file path", name, stringValue)
+			return nil, fmt.Errorf("packages of import %q (value %q) is not a csv file or a lockfile path", name, stringValue)
 		}
 	}
 
 	return viper.GetStringMapStringSlice(key), nil
 }
 
+// The separator between a version and a format specific suffix, for example a Maven packaging
+// or a generic package file name.
+const versionSuffixSeparator = ":"
+
 func getPackagesMapFromCSV(csvFilepath string) (map[string][]string, error) {
 	file, err := os.Open(csvFilepath)
 	if err != nil {
@@ -53,7 +59,7 @@ func getPackagesMapFromCSV(csvFilepath string) (map[string][]string, error) {
 	}
 
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 2
+	reader.FieldsPerRecord = -1
 	reader.ReuseRecord = true
 	packages := map[string][]string{}
 
@@ -68,9 +74,19 @@ func getPackagesMapFromCSV(csvFilepath string) (map[string][]string, error) {
 			return nil, err
 		}
 
+		if len(record) != 2 && len(record) != 3 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("record on line %d of %q: wrong number of fields, expected 2 or 3", line, csvFilepath)
+		}
+
 		name := record[0]
 		version := record[1]
 
+		// The optional third column is appended to the version, like the Maven packaging.
+		if len(record) == 3 {
+			version = fmt.Sprintf("%s%s%s", version, versionSuffixSeparator, record[2])
+		}
+
 		if packages[name] == nil {
 			packages[name] = make([]string, 0, 1)
 		}
@@ -82,6 +82,19 @@ func TestGetPackagesMapWithCsv(t *testing.T) {
 				"package3":       {"2.3.5"},
 			},
 		},
+		{
+			name:        "csv with a third column",
+			csvFilePath: "../testdata/csv/generic.csv",
+			packages: map[string][]string{
+				"my_binary": {"1.2.3:my_binary-linux-amd64", "1.2.3:my_binary-darwin-arm64"},
+				"my_tool":   {"0.4.0:tool.tar.gz"},
+			},
+		},
+		{
+			name:        "csv with too many columns",
+			csvFilePath: "../testdata/csv/invalid.csv",
+			erroneous:   true,
+		},
 	}
 
 	for _, spec := range tests {
@@ -99,3 +112,122 @@ func TestGetPackagesMapWithCsv(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPackagesMapWithLockfile(t *testing.T) {
+	npmPackages := map[string][]string{
+		"@scope/package": {"2.1.0"},
+		"lodash":         {"3.10.1", "4.17.21"},
+	}
+
+	tests := []struct {
+		name          string
+		lockfilePath  string
+		packages      map[string][]string
+		expectedError string
+	}{
+		{
+			name:         "package-lock.json",
+			lockfilePath: "../testdata/lockfiles/package-lock.json",
+			packages: map[string][]string{
+				"@scope/package": {"2.1.0"},
+				"lodash":         {"3.10.1", "4.17.21"},
+				"real-name":      {"1.0.0"},
+			},
+		},
+		{
+			name:         "package-lock.json version 1",
+			lockfilePath: "../testdata/lockfiles/npm_v1/package-lock.json",
+			packages:     npmPackages,
+		},
+		{
+			name:         "yarn.lock",
+			lockfilePath: "../testdata/lockfiles/yarn.lock",
+			packages:     npmPackages,
+		},
+		{
+			name:         "yarn.lock berry",
+			lockfilePath: "../testdata/lockfiles/yarn_berry/yarn.lock",
+			packages:     npmPackages,
+		},
+		{
+			name:         "pnpm-lock.yaml",
+			lockfilePath: "../testdata/lockfiles/pnpm-lock.yaml",
+			packages:     npmPackages,
+		},
+		{
+			name:         "pnpm-lock.yaml version 6",
+			lockfilePath: "../testdata/lockfiles/pnpm_v6/pnpm-lock.yaml",
+			packages:     npmPackages,
+		},
+		{
+			name:         "pnpm-lock.yaml version 5",
+			lockfilePath: "../testdata/lockfiles/pnpm_v5/pnpm-lock.yaml",
+			packages: map[string][]string{
+				"@scope/package":   {"2.1.0"},
+				"@types/react-dom": {"18.0.11"},
+				"lodash":           {"3.10.1", "4.17.21"},
+				"string_decoder":   {"1.3.0"},
+			},
+		},
+		{
+			name:         "poetry.lock",
+			lockfilePath: "../testdata/lockfiles/poetry.lock",
+			packages: map[string][]string{
+				"certifi":  {"2023.7.22"},
+				"requests": {"2.31.0"},
+			},
+		},
+		{
+			name:         "requirements.txt",
+			lockfilePath: "../testdata/lockfiles/requirements.txt",
+			packages: map[string][]string{
+				"certifi":  {"2023.7.22"},
+				"requests": {"2.31.0"},
+				"urllib3":  {"2.0.4"},
+			},
+		},
+		{
+			name:          "requirements.txt without pins",
+			lockfilePath:  "../testdata/lockfiles/requirements-unpinned.txt",
+			expectedError: `invalid lockfile "../testdata/lockfiles/requirements-unpinned.txt": requirement "requests>=2.0" on line 1 is not pinned with ==`,
+		},
+		{
+			name:         "packages.lock.json",
+			lockfilePath: "../testdata/lockfiles/packages.lock.json",
+			packages: map[string][]string{
+				"Newtonsoft.Json": {"13.0.1"},
+				"System.Memory":   {"4.5.4", "4.5.5"},
+			},
+		},
+		{
+			name:         "gradle.lockfile",
+			lockfilePath: "../testdata/lockfiles/gradle/gradle.lockfile",
+			packages: map[string][]string{
+				"com.google.guava:failureaccess": {"1.0.1"},
+				"com.google.guava:guava":         {"32.1.2-jre"},
+				"org.junit:junit-bom":            {"5.10.0"},
+			},
+		},
+		{
+			name:          "missing lockfile",
+			lockfilePath:  "../testdata/lockfiles/missing/yarn.lock",
+			expectedError: "open ../testdata/lockfiles/missing/yarn.lock: no such file or directory",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.lockfilePath)
+			packages, err := GetPackagesMap("import1")
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.packages, packages)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package github renders the imports into a GitHub Actions workflow.
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultFileName = "import_packages.yml"
+	runner          = "ubuntu-latest"
+	// A matrix generates at most 256 jobs. See https://docs.github.com/en/actions/using-jobs/using-a-matrix-for-your-jobs
+	maxMatrixSize = 256
+)
+
+var invalidIdRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// Backend renders the imports into a workflow started manually: a job per import, run in the image
+// of the import, with a matrix over its package versions. The environment variables that the
+// scripts use but don't set, like $DESTINATION_TOKEN in a token, are read from the secrets of the
+// repository.
+type Backend struct{}
+
+type workflow struct {
+	Name string              `yaml:"name"`
+	On   map[string]struct{} `yaml:"on"`
+	Env  map[string]string   `yaml:"env,omitempty"`
+	Jobs map[string]job      `yaml:"jobs"`
+}
+
+type job struct {
+	Name      string            `yaml:"name"`
+	RunsOn    string            `yaml:"runs-on"`
+	Container container         `yaml:"container"`
+	Strategy  strategy          `yaml:"strategy"`
+	Env       map[string]string `yaml:"env"`
+	Steps     []step            `yaml:"steps"`
+}
+
+type container struct {
+	Image string `yaml:"image"`
+}
+
+type strategy struct {
+	FailFast bool   `yaml:"fail-fast"`
+	Matrix   matrix `yaml:"matrix"`
+}
+
+type matrix struct {
+	Include []map[string]string `yaml:"include"`
+}
+
+type step struct {
+	Name string `yaml:"name"`
+	Run  string `yaml:"run"`
+}
+
+// Render returns the workflow of the imports. The imports without packages don't get a job and the
+// package versions of an import are split into several jobs above the matrix size limit.
+func (b Backend) Render(imports []pipeline.Import) ([]byte, error) {
+	w := workflow{
+		Name: "Import packages",
+		On:   map[string]struct{}{"workflow_dispatch": {}},
+		Env:  map[string]string{},
+		Jobs: map[string]job{},
+	}
+
+	for _, i := range imports {
+		if len(i.Packages) == 0 {
+			continue
+		}
+
+		variables := i.VariableNames()
+		for _, secret := range i.Secrets() {
+			w.Env[secret] = fmt.Sprintf("${{ secrets.%s }}", secret)
+		}
+
+		chunks := chunk(i.Packages, maxMatrixSize)
+		for n, packages := range chunks {
+			id, name := jobId(i.Name), i.Name
+			if len(chunks) > 1 {
+				id = fmt.Sprintf("%s_%d", id, n+1)
+				name = fmt.Sprintf("%s (%d/%d)", name, n+1, len(chunks))
+			}
+			if _, ok := w.Jobs[id]; ok {
+				return nil, fmt.Errorf("import %q has the same job id %q as another import", i.Name, id)
+			}
+
+			w.Jobs[id] = newJob(name, i, packages, variables)
+		}
+	}
+
+	return yaml.Marshal(w)
+}
+
+// MaxSize returns 0, the size of a workflow file is not limited.
+func (b Backend) MaxSize() int64 {
+	return 0
+}
+
+// DefaultFileName returns import_packages.yml. The file goes in the .github/workflows directory of a
+// repository.
+func (b Backend) DefaultFileName() string {
+	return defaultFileName
+}
+
+func newJob(name string, i pipeline.Import, packages []pipeline.Package, variables []string) job {
+	include := make([]map[string]string, 0, len(packages))
+	for _, p := range packages {
+		include = append(include, p.AllVariables())
+	}
+
+	env := make(map[string]string, len(variables))
+	for _, v := range variables {
+		env[v] = fmt.Sprintf("${{ matrix.%s }}", v)
+	}
+
+	return job{
+		Name:      name,
+		RunsOn:    runner,
+		Container: container{Image: i.Image},
+		Strategy: strategy{
+			FailFast: false,
+			Matrix:   matrix{Include: include},
+		},
+		Env: env,
+		Steps: []step{
+			{
+				Name: "Import ${{ matrix.PACKAGE_NAME }} ${{ matrix.PACKAGE_VERSION }}",
+				Run:  strings.Join(i.Scripts, "\n"),
+			},
+		},
+	}
+}
+
+func chunk(packages []pipeline.Package, size int) [][]pipeline.Package {
+	chunks := [][]pipeline.Package{}
+	for len(packages) > size {
+		chunks = append(chunks, packages[:size])
+		packages = packages[size:]
+	}
+
+	return append(chunks, packages)
+}
+
+// jobId replaces the characters that a job id can't have by _. A job id starts with a letter or _.
+func jobId(importName string) string {
+	id := invalidIdRegexp.ReplaceAllString(importName, "_")
+	if len(id) == 0 || (id[0] >= '0' && id[0] <= '9') || id[0] == '-' {
+		id = "_" + id
+	}
+
+	return id
+}
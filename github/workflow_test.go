@@ -0,0 +1,73 @@
+package github
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRender(t *testing.T) {
+	manyPackages := make([]pipeline.Package, 0, maxMatrixSize+1)
+	for n := 0; n <= maxMatrixSize; n++ {
+		manyPackages = append(manyPackages, pipeline.Package{Name: "package", Version: fmt.Sprintf("1.0.%d", n)})
+	}
+
+	tests := []struct {
+		name         string
+		imports      []pipeline.Import
+		expectedJobs map[string]int
+		expectedEnv  map[string]string
+	}{
+		{
+			name: "with secrets",
+			imports: []pipeline.Import{
+				{
+					Name:    "import1",
+					Image:   "alpine",
+					Scripts: []string{`export SOURCE_URL="https://source.test"`, `curl -u "user:$SOURCE_TOKEN" $SOURCE_URL/$PACKAGE_FILE`, `echo "${DESTINATION_TOKEN}"`},
+					Packages: []pipeline.Package{
+						{Name: "package1", Version: "1.0.0", Variables: map[string]string{"PACKAGE_FILE": "package1.tgz"}},
+					},
+				},
+			},
+			expectedJobs: map[string]int{"import1": 1},
+			expectedEnv: map[string]string{
+				"DESTINATION_TOKEN": "${{ secrets.DESTINATION_TOKEN }}",
+				"SOURCE_TOKEN":      "${{ secrets.SOURCE_TOKEN }}",
+			},
+		},
+		{
+			name: "over the matrix size limit",
+			imports: []pipeline.Import{
+				{Name: "import 1", Image: "alpine", Scripts: []string{"echo $PACKAGE_NAME"}, Packages: manyPackages},
+				{Name: "import2", Image: "alpine", Scripts: []string{"echo $PACKAGE_NAME"}},
+			},
+			expectedJobs: map[string]int{"import_1_1": maxMatrixSize, "import_1_2": 1},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			content, err := Backend{}.Render(spec.imports)
+			require.NoError(t, err)
+
+			w := workflow{}
+			require.NoError(t, yaml.Unmarshal(content, &w))
+
+			jobs := map[string]int{}
+			for id, j := range w.Jobs {
+				jobs[id] = len(j.Strategy.Matrix.Include)
+			}
+			require.Equal(t, spec.expectedJobs, jobs)
+
+			if spec.expectedEnv != nil {
+				require.Equal(t, spec.expectedEnv, w.Env)
+			} else {
+				require.Empty(t, w.Env)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ go 1.18
 replace github.com/spf13/viper => gitlab.com/gitlab-org/ci-cd/package-stage/libs/golang/viper-fork v0.0.0-20230511141318-09c40f7e2cec
 
 require (
+	github.com/Masterminds/semver/v3 v3.2.1
 	github.com/go-playground/validator/v10 v10.11.1
+	github.com/pelletier/go-toml/v2 v2.0.5
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/viper v0.0.0-00010101000000-000000000000
 	github.com/stretchr/testify v1.8.1
@@ -24,7 +26,6 @@ require (
 	github.com/magiconair/properties v1.8.6 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
 	github.com/pelletier/go-toml v1.9.5 // indirect
-	github.com/pelletier/go-toml/v2 v2.0.5 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/afero v1.9.2 // indirect
 	github.com/spf13/cast v1.5.0 // indirect
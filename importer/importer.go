@@ -0,0 +1,305 @@
+// Package importer carries out the imports of a configuration directly from this process: each
+// package version is downloaded from the source registry and uploaded to the destination registry
+// over HTTP, without a KhulnaSoft pipeline.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/logger"
+	"github.com/khulnasoft/packages-registry/registry"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/util"
+)
+
+// Importer is the object that from the configuration will copy all the packages. As such, it has a
+// single exported function: Import.
+type Importer struct {
+	config  *config.Configuration
+	client  *transfer.Client
+	workers int
+}
+
+// Package is a package version of an import.
+type Package struct {
+	ImportName, Name, Version string
+}
+
+func (p Package) String() string {
+	return fmt.Sprintf("%s %s in import %q", p.Name, p.Version, p.ImportName)
+}
+
+// Report lists the outcome of the packages of an import run.
+type Report struct {
+	Imported   []Package         // The packages copied to their destination.
+	Skipped    []Package         // The packages already in their destination, with on_existing: skip.
+	Failed     map[Package]error // The packages that couldn't be copied, with their error.
+	NotStarted int               // The number of packages not tried because the import was canceled.
+}
+
+// job is a package version to import with everything needed by a worker.
+type job struct {
+	pkg        Package
+	pkgsImport config.Import
+	registry   registry.Registry
+	transferer registry.Transferer
+	limiters   []limiter
+	lock       *sync.Mutex // Shared by the jobs that can't run at the same time, nil for none.
+}
+
+// limiter bounds the number of packages imported at the same time from or to a registry.
+type limiter chan struct{}
+
+func (l limiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l limiter) release() {
+	<-l
+}
+
+var (
+	errNotStarted = errors.New("not started")
+	errSkipped    = errors.New("skipped")
+)
+
+// Import copies the packages of all the imports with a pool of workers. The registries of all the
+// imports are created first so that an invalid import fails before any copy. A failing package
+// doesn't stop the other ones: the failures are logged and counted in the returned error.
+//
+// When the context is canceled, no other package is started and the ongoing requests are canceled.
+// The returned report tells which packages were imported before the cancellation. It's never nil:
+// when the registries or the packages can't be resolved, like on a cancellation during the
+// resolution, it's empty.
+func (i *Importer) Import(ctx context.Context) (*Report, error) {
+	report := &Report{Imported: []Package{}, Skipped: []Package{}, Failed: map[Package]error{}}
+
+	jobs, err := i.jobs(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan job)
+
+	for w := 0; w < i.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				err := i.run(ctx, j)
+
+				mutex.Lock()
+				switch {
+				case errors.Is(err, errNotStarted):
+					report.NotStarted++
+				case errors.Is(err, errSkipped):
+					logger.LogInfo(fmt.Sprintf("Skipped %s: it already exists in the destination", j.pkg))
+					report.Skipped = append(report.Skipped, j.pkg)
+				case err != nil:
+					logger.LogError(fmt.Sprintf("Error while importing %s:", j.pkg), err)
+					report.Failed[j.pkg] = err
+				default:
+					logger.LogInfo(fmt.Sprintf("Imported %s", j.pkg))
+					report.Imported = append(report.Imported, j.pkg)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	queued := 0
+dispatch:
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+			queued++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	report.NotStarted += len(jobs) - queued
+	sortPackages(report.Imported)
+	sortPackages(report.Skipped)
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	if len(report.Failed) != 0 {
+		return report, fmt.Errorf("%d of %d packages failed to import", len(report.Failed), len(jobs))
+	}
+
+	return report, nil
+}
+
+// run imports a package once its registries allow it, unless the import skips the packages already
+// in the destination and this one is there. The limiters are always acquired in the same
+// order so that two workers can't wait for each other.
+func (i *Importer) run(ctx context.Context, j job) error {
+	for index, l := range j.limiters {
+		if err := l.acquire(ctx); err != nil {
+			for _, acquired := range j.limiters[:index] {
+				acquired.release()
+			}
+			return errNotStarted
+		}
+	}
+	defer func() {
+		for _, l := range j.limiters {
+			l.release()
+		}
+	}()
+
+	if ctx.Err() != nil {
+		return errNotStarted
+	}
+
+	// The lock is taken after the limiters, so that a job holding it never waits for a limiter.
+	if j.lock != nil {
+		j.lock.Lock()
+		defer j.lock.Unlock()
+	}
+
+	ok, err := registry.ShouldImport(ctx, i.client, j.registry, j.pkgsImport, j.pkg.ImportName, j.pkg.Name, j.pkg.Version)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errSkipped
+	}
+
+	return j.transferer.Transfer(ctx, i.client, j.pkg.Name, j.pkg.Version)
+}
+
+// jobs returns the package versions of all the imports, in the order of the configuration. The
+// package name patterns and the version specs are resolved against the source registries.
+func (i *Importer) jobs(ctx context.Context) ([]job, error) {
+	importNames := util.OrderedMapKeysOf(i.config.Imports)
+	limiters := i.limiters()
+	locks := map[string]*sync.Mutex{}
+	jobs := []job{}
+
+	for _, importName := range importNames {
+		pkgsImport := i.config.Imports[importName]
+
+		r, err := registry.GetRegistry(pkgsImport, importName)
+		if err != nil {
+			return nil, err
+		}
+
+		transferer, ok := r.(registry.Transferer)
+		if !ok {
+			return nil, fmt.Errorf("the %q type of import %q doesn't support in-process imports yet", pkgsImport.Type, importName)
+		}
+
+		importLimiters := []limiter{}
+		for _, url := range sortedUrls(pkgsImport) {
+			if l, ok := limiters[url]; ok {
+				importLimiters = append(importLimiters, l)
+			}
+		}
+
+		packagesMap, err := config.GetPackagesMap(importName)
+		if err != nil {
+			return nil, err
+		}
+
+		packagesMap, err = registry.ResolvePackages(ctx, i.client, r, pkgsImport, importName, packagesMap)
+		if err != nil {
+			return nil, err
+		}
+
+		locker, _ := r.(registry.TransferLocker)
+		for _, name := range util.OrderedMapKeysOf(packagesMap) {
+			for _, version := range packagesMap[name] {
+				j := job{
+					pkg:        Package{ImportName: importName, Name: name, Version: version},
+					pkgsImport: pkgsImport,
+					registry:   r,
+					transferer: transferer,
+					limiters:   importLimiters,
+				}
+				if locker != nil {
+					if key := locker.TransferLock(name, version); len(key) != 0 {
+						if _, ok := locks[key]; !ok {
+							locks[key] = &sync.Mutex{}
+						}
+						j.lock = locks[key]
+					}
+				}
+				jobs = append(jobs, j)
+			}
+		}
+	}
+
+	return jobs, nil
+}
+
+// limiters returns a limiter for each registry with a max_concurrency. A registry is identified by
+// its url so that the limit is shared by all the imports using it. When these imports set different
+// limits, the lowest one is used.
+func (i *Importer) limiters() map[string]limiter {
+	limits := map[string]int{}
+	for _, pkgsImport := range i.config.Imports {
+		for _, r := range []config.Registry{pkgsImport.Source, pkgsImport.Destination} {
+			url := registryUrl(r)
+			if r.MaxConcurrency > 0 && (limits[url] == 0 || r.MaxConcurrency < limits[url]) {
+				limits[url] = r.MaxConcurrency
+			}
+		}
+	}
+
+	limiters := make(map[string]limiter, len(limits))
+	for url, limit := range limits {
+		limiters[url] = make(limiter, limit)
+	}
+
+	return limiters
+}
+
+func sortPackages(packages []Package) {
+	sort.Slice(packages, func(a, b int) bool {
+		return packages[a].String() < packages[b].String()
+	})
+}
+
+func registryUrl(r config.Registry) string {
+	return strings.TrimSuffix(r.URL, "/")
+}
+
+func sortedUrls(pkgsImport config.Import) []string {
+	urls := []string{registryUrl(pkgsImport.Source), registryUrl(pkgsImport.Destination)}
+	sort.Strings(urls)
+
+	return urls
+}
+
+// NewImporter will create a new Importer for the configuration. workers is the number of packages
+// imported at the same time, at least 1.
+func NewImporter(config *config.Configuration, client *transfer.Client, workers int) *Importer {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Importer{
+		config:  config,
+		client:  client,
+		workers: workers,
+	}
+}
@@ -0,0 +1,356 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name            string
+		importType      string
+		onExisting      string
+		packages        map[string][]string
+		expectedUploads []string
+		expectedLogs    []string
+		expectedError   string
+	}{
+		{
+			name:            "all packages imported",
+			importType:      "generic",
+			packages:        map[string][]string{"package": {"1.0.0:file.txt", "2.0.0:file.txt"}},
+			expectedUploads: []string{"/package/1.0.0/file.txt", "/package/2.0.0/file.txt"},
+			expectedLogs:    []string{`Imported package 1.0.0:file.txt in import "import1"`, `Imported package 2.0.0:file.txt in import "import1"`},
+		},
+		{
+			name:            "failing package",
+			importType:      "generic",
+			packages:        map[string][]string{"missing": {"1.0.0:file.txt"}, "package": {"1.0.0:file.txt"}},
+			expectedUploads: []string{"/package/1.0.0/file.txt"},
+			expectedLogs:    []string{`Error while importing missing 1.0.0:file.txt in import "import1"`, "/missing/1.0.0/file.txt returned 404 Not Found"},
+			expectedError:   "1 of 2 packages failed to import",
+		},
+		{
+			name:            "existing package skipped",
+			importType:      "generic",
+			onExisting:      config.OnExistingSkip,
+			packages:        map[string][]string{"existing": {"1.0.0:file.txt"}, "package": {"1.0.0:file.txt"}},
+			expectedUploads: []string{"/package/1.0.0/file.txt"},
+			expectedLogs:    []string{`Skipped existing 1.0.0:file.txt in import "import1": it already exists in the destination`},
+		},
+		{
+			name:            "existing package failed",
+			importType:      "generic",
+			onExisting:      config.OnExistingFail,
+			packages:        map[string][]string{"existing": {"1.0.0:file.txt"}, "package": {"1.0.0:file.txt"}},
+			expectedUploads: []string{"/package/1.0.0/file.txt"},
+			expectedLogs:    []string{`existing 1.0.0:file.txt of import "import1" already exists in the destination`},
+			expectedError:   "1 of 2 packages failed to import",
+		},
+		{
+			name:            "unsupported type",
+			importType:      "helm",
+			packages:        map[string][]string{"package": {"1.0.0"}},
+			expectedUploads: []string{},
+			expectedError:   `the "helm" type of import "import1" doesn't support in-process imports yet`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			buff := new(strings.Builder)
+			log.SetOutput(buff)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+			t.Cleanup(viper.Reset)
+
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/missing/") {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte("content"))
+			}))
+			t.Cleanup(source.Close)
+
+			uploads := []string{}
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					if !strings.HasPrefix(r.URL.Path, "/existing/") {
+						http.NotFound(w, r)
+					}
+					return
+				}
+				io.ReadAll(r.Body)
+				uploads = append(uploads, r.URL.Path)
+			}))
+			t.Cleanup(destination.Close)
+
+			viper.Set("import1.packages", spec.packages)
+			configuration := &config.Configuration{
+				Imports: map[string]config.Import{
+					"import1": {
+						Type:        spec.importType,
+						OnExisting:  spec.onExisting,
+						Source:      config.Registry{URL: source.URL},
+						Destination: config.Registry{
+							URL: destination.URL,
+							Credentials: config.Credentials{
+								Token:                "TOKEN",
+								AdditionalParameters: map[string]string{"username": "user"},
+							},
+						},
+					},
+				},
+			}
+
+			_, err := NewImporter(configuration, transfer.NewClient(nil), 1).Import(context.Background())
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, spec.expectedUploads, uploads)
+			for _, expectedLog := range spec.expectedLogs {
+				require.Contains(t, buff.String(), expectedLog)
+			}
+		})
+	}
+}
+
+func TestImportConcurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		workers        int
+		maxConcurrency int
+		expectedMax    int32
+	}{
+		{
+			name:        "without limit",
+			workers:     4,
+			expectedMax: 4,
+		},
+		{
+			name:           "with destination limit",
+			workers:        8,
+			maxConcurrency: 2,
+			expectedMax:    2,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			log.SetOutput(io.Discard)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+			t.Cleanup(viper.Reset)
+
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("content"))
+			}))
+			t.Cleanup(source.Close)
+
+			var current, max int32
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				value := atomic.AddInt32(&current, 1)
+				for {
+					observed := atomic.LoadInt32(&max)
+					if value <= observed || atomic.CompareAndSwapInt32(&max, observed, value) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&current, -1)
+			}))
+			t.Cleanup(destination.Close)
+
+			versions := []string{}
+			for v := 0; v < 16; v++ {
+				versions = append(versions, fmt.Sprintf("1.0.%d:file.txt", v))
+			}
+			viper.Set("import1.packages", map[string][]string{"package": versions})
+
+			configuration := &config.Configuration{
+				Imports: map[string]config.Import{
+					"import1": {
+						Type:   "generic",
+						Source: config.Registry{URL: source.URL},
+						Destination: config.Registry{
+							URL:            destination.URL,
+							MaxConcurrency: spec.maxConcurrency,
+							Credentials: config.Credentials{
+								Token:                "TOKEN",
+								AdditionalParameters: map[string]string{"username": "user"},
+							},
+						},
+					},
+				},
+			}
+
+			report, err := NewImporter(configuration, transfer.NewClient(nil), spec.workers).Import(context.Background())
+
+			require.NoError(t, err)
+			require.Len(t, report.Imported, len(versions))
+			require.LessOrEqual(t, max, spec.expectedMax)
+			require.Greater(t, max, int32(1))
+		})
+	}
+}
+
+func TestImportCancellation(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	t.Cleanup(viper.Reset)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/second/") {
+			// Simulates an interruption during the second package.
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	t.Cleanup(source.Close)
+
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(destination.Close)
+
+	viper.Set("import1.packages", map[string][]string{
+		"first":  {"1.0.0:file.txt"},
+		"second": {"1.0.0:file.txt"},
+		"third":  {"1.0.0:file.txt", "2.0.0:file.txt"},
+	})
+	configuration := &config.Configuration{
+		Imports: map[string]config.Import{
+			"import1": {
+				Type:        "generic",
+				Source:      config.Registry{URL: source.URL},
+				Destination: config.Registry{URL: destination.URL},
+			},
+		},
+	}
+
+	report, err := NewImporter(configuration, transfer.NewClient(nil), 1).Import(ctx)
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []Package{{ImportName: "import1", Name: "first", Version: "1.0.0:file.txt"}}, report.Imported)
+	require.Len(t, report.Failed, 1)
+	require.ErrorIs(t, report.Failed[Package{ImportName: "import1", Name: "second", Version: "1.0.0:file.txt"}], context.Canceled)
+	require.Equal(t, 2, report.NotStarted)
+}
+
+func TestImportCancellationDuringResolution(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	t.Cleanup(viper.Reset)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulates an interruption while the latest version is resolved.
+		cancel()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(source.Close)
+
+	viper.Set("import1.packages", map[string][]string{"package1": {"latest:1"}})
+	configuration := &config.Configuration{
+		Imports: map[string]config.Import{
+			"import1": {
+				Type:        "npm",
+				Source:      config.Registry{URL: source.URL},
+				Destination: config.Registry{URL: source.URL},
+			},
+		},
+	}
+
+	report, err := NewImporter(configuration, transfer.NewClient(nil), 1).Import(ctx)
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, &Report{Imported: []Package{}, Skipped: []Package{}, Failed: map[Package]error{}}, report)
+}
+
+func TestImportMavenMetadata(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	t.Cleanup(viper.Reset)
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha1") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("pom"))
+	}))
+	t.Cleanup(source.Close)
+
+	var mutex sync.Mutex
+	var metadata string
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/maven-metadata.xml") {
+			io.ReadAll(r.Body)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Method == http.MethodGet {
+			if len(metadata) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(metadata))
+			return
+		}
+		// Widens the window between the read and the update of the metadata file.
+		time.Sleep(5 * time.Millisecond)
+		body, _ := io.ReadAll(r.Body)
+		metadata = string(body)
+	}))
+	t.Cleanup(destination.Close)
+
+	versions := []string{}
+	for v := 0; v < 8; v++ {
+		versions = append(versions, fmt.Sprintf("1.0.%d:pom", v))
+	}
+	viper.Set("import1.packages", map[string][]string{"com.example:artifact": versions})
+
+	configuration := &config.Configuration{
+		Imports: map[string]config.Import{
+			"import1": {
+				Type:        "maven",
+				Source:      config.Registry{URL: source.URL},
+				Destination: config.Registry{URL: destination.URL},
+			},
+		},
+	}
+
+	report, err := NewImporter(configuration, transfer.NewClient(nil), 4).Import(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, report.Imported, len(versions))
+	for v := range versions {
+		require.Contains(t, metadata, fmt.Sprintf("<version>1.0.%d</version>", v))
+	}
+	require.Contains(t, metadata, "<latest>1.0.7</latest>")
+}
@@ -0,0 +1,179 @@
+// Package jenkins renders the imports into a declarative Jenkins pipeline.
+package jenkins
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/khulnasoft/packages-registry/util"
+)
+
+const (
+	defaultFileName = "Jenkinsfile"
+	// dataMethodSize is the maximum size of the source of a method returning package versions. A
+	// Groovy method is compiled into at most 64 KiB of bytecode, "Method code too large" otherwise,
+	// and a package version of a list literal takes fewer bytes of bytecode than of source.
+	dataMethodSize = 32 * 1024
+	// maxStrings is the maximum number of distinct names and values of the package versions. A
+	// string literal takes 2 of the 65535 entries of the constant pool of the class of the Jenkinsfile.
+	maxStrings = 30000
+)
+
+// importPackagesMethod runs the import of each package version in a parallel branch, in a Docker
+// container of the image of the import, with the variables of the package version in the
+// environment. The branches are built in a loop, so the pipeline doesn't grow with the packages.
+const importPackagesMethod = `def importPackages(String importName, String image, List packages, Closure importPackage) {
+    def branches = [:]
+    for (int n = 0; n < packages.size(); n++) {
+        def variables = packages[n]
+        branches[importName + ':' + variables.PACKAGE_NAME + ':' + variables.PACKAGE_VERSION] = {
+            node {
+                docker.image(image).inside {
+                    withEnv(packageEnvironment(variables)) {
+                        importPackage()
+                    }
+                }
+            }
+        }
+    }
+    parallel branches
+}
+
+@NonCPS
+def packageEnvironment(Map variables) {
+    return variables.collect { key, value -> key + '=' + value }
+}
+
+`
+
+var invalidNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Backend renders the imports into a Jenkinsfile: a stage per import with a parallel branch per
+// package version, run in a Docker container with the image of the import. The scripts of an import
+// are in a method called by its branches, and its package versions are the data returned by other
+// methods. The environment variables that the scripts use but don't set, like $DESTINATION_TOKEN in
+// a token, are read from the credentials with the same id.
+type Backend struct{}
+
+// Render returns the Jenkinsfile of the imports. The imports without packages don't get a stage.
+func (b Backend) Render(imports []pipeline.Import) ([]byte, error) {
+	methods := strings.Builder{}
+	stages := strings.Builder{}
+	secrets := map[string]struct{}{}
+	names := map[string]string{}
+	values := map[string]struct{}{}
+
+	for _, i := range imports {
+		if len(i.Packages) == 0 {
+			continue
+		}
+
+		method := methodName(i.Name)
+		if other, ok := names[method]; ok {
+			return nil, fmt.Errorf("import %q has the same method name %q as the import %q", i.Name, method, other)
+		}
+		names[method] = i.Name
+
+		for _, secret := range i.Secrets() {
+			secrets[secret] = struct{}{}
+		}
+
+		methods.WriteString(fmt.Sprintf("def %s() {\n    sh '''%s'''\n}\n\n", method, escapeMultiline(strings.Join(i.Scripts, "\n"))))
+
+		calls := []string{}
+		for n, data := range packagesData(i.Packages, values) {
+			dataMethod := fmt.Sprintf("packages_%s_%d", strings.TrimPrefix(method, "import_"), n+1)
+			methods.WriteString(fmt.Sprintf("@NonCPS\ndef %s() {\n    return [\n%s    ]\n}\n\n", dataMethod, data))
+			calls = append(calls, dataMethod+"()")
+		}
+
+		stages.WriteString(fmt.Sprintf("        stage(%s) {\n            steps {\n                script {\n", quote(i.Name)))
+		stages.WriteString(fmt.Sprintf("                    importPackages(%s, %s, %s) { %s() }\n", quote(i.Name), quote(i.Image), strings.Join(calls, " + "), method))
+		stages.WriteString("                }\n            }\n        }\n")
+	}
+
+	if len(values) > maxStrings {
+		return nil, fmt.Errorf("the package versions have %d distinct names and values which is over the limit of %d of a Jenkinsfile, split the imports across several configurations", len(values), maxStrings)
+	}
+
+	jenkinsfile := strings.Builder{}
+	jenkinsfile.WriteString(methods.String())
+	if len(names) != 0 {
+		jenkinsfile.WriteString(importPackagesMethod)
+	}
+	jenkinsfile.WriteString("pipeline {\n    agent none\n")
+	if len(secrets) != 0 {
+		jenkinsfile.WriteString("    environment {\n")
+		for _, secret := range util.OrderedMapKeysOf(secrets) {
+			jenkinsfile.WriteString(fmt.Sprintf("        %s = credentials(%s)\n", secret, quote(secret)))
+		}
+		jenkinsfile.WriteString("    }\n")
+	}
+	jenkinsfile.WriteString("    stages {\n")
+	jenkinsfile.WriteString(stages.String())
+	jenkinsfile.WriteString("    }\n}\n")
+
+	return []byte(jenkinsfile.String()), nil
+}
+
+// MaxSize returns 0, the size of a Jenkinsfile is not limited: its pipeline doesn't grow with the
+// package versions and their data is split across methods under the size limit of a method.
+func (b Backend) MaxSize() int64 {
+	return 0
+}
+
+// DefaultFileName returns Jenkinsfile.
+func (b Backend) DefaultFileName() string {
+	return defaultFileName
+}
+
+// packagesData returns the list elements of the package versions, a map of their variables per
+// line, split into parts of dataMethodSize at most. The names and the values of the variables are
+// added to values.
+func packagesData(packages []pipeline.Package, values map[string]struct{}) []string {
+	parts := []string{}
+	part := strings.Builder{}
+
+	for _, p := range packages {
+		variables := p.AllVariables()
+		entries := make([]string, 0, len(variables))
+		for _, key := range util.OrderedMapKeysOf(variables) {
+			entries = append(entries, fmt.Sprintf("%s: %s", quote(key), quote(variables[key])))
+			values[key] = struct{}{}
+			values[variables[key]] = struct{}{}
+		}
+
+		line := fmt.Sprintf("        [%s],\n", strings.Join(entries, ", "))
+		if part.Len() != 0 && part.Len()+len(line) > dataMethodSize {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		part.WriteString(line)
+	}
+
+	return append(parts, part.String())
+}
+
+// methodName returns the name of the method of an import: import_ followed by the import name,
+// where the characters that a name can't have are replaced by _.
+func methodName(importName string) string {
+	return "import_" + invalidNameRegexp.ReplaceAllString(importName, "_")
+}
+
+// quote returns a Groovy single quoted string, where $ is not interpolated.
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+// escapeMultiline escapes the content of a Groovy triple single quoted string, including a single
+// quote at the end that would run into the closing quotes.
+func escapeMultiline(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	if strings.HasSuffix(value, "'") {
+		value = strings.TrimSuffix(value, "'") + `\'`
+	}
+	return strings.ReplaceAll(value, "'''", `\'\'\'`)
+}
@@ -0,0 +1,99 @@
+package jenkins
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name            string
+		imports         []pipeline.Import
+		expectedOutputs []string
+	}{
+		{
+			name: "with credentials",
+			imports: []pipeline.Import{
+				{
+					Name:     "import1",
+					Image:    "alpine",
+					Scripts:  []string{`curl -u "user:$SOURCE_TOKEN" https://source.test`, `echo "${DESTINATION_TOKEN}"`},
+					Packages: []pipeline.Package{{Name: "package1", Version: "1.0.0"}},
+				},
+			},
+			expectedOutputs: []string{
+				"    environment {\n        DESTINATION_TOKEN = credentials('DESTINATION_TOKEN')\n        SOURCE_TOKEN = credentials('SOURCE_TOKEN')\n    }\n",
+			},
+		},
+		{
+			name: "with quotes and backslashes",
+			imports: []pipeline.Import{
+				{
+					Name:     "it's",
+					Image:    "alpine",
+					Scripts:  []string{`printf 'a\n'`, `echo '''x`, `echo '''`},
+					Packages: []pipeline.Package{{Name: "package1", Version: "1.0.0", Variables: map[string]string{"PACKAGE_FILE": `a'b\c`}}},
+				},
+			},
+			expectedOutputs: []string{
+				"def import_it_s() {\n    sh '''printf 'a\\\\n'\necho \\'\\'\\'x\necho ''\\''''\n}\n",
+				"stage('it\\'s') {",
+				"['PACKAGE_FILE': 'a\\'b\\\\c', 'PACKAGE_NAME': 'package1', 'PACKAGE_VERSION': '1.0.0'],",
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			content, err := Backend{}.Render(spec.imports)
+			require.Nil(t, err)
+
+			for _, expectedOutput := range spec.expectedOutputs {
+				require.Contains(t, string(content), expectedOutput)
+			}
+		})
+	}
+}
+
+func TestRenderSameMethodName(t *testing.T) {
+	packages := []pipeline.Package{{Name: "package1", Version: "1.0.0"}}
+	_, err := Backend{}.Render([]pipeline.Import{{Name: "import-1", Packages: packages}, {Name: "import_1", Packages: packages}})
+
+	require.EqualError(t, err, `import "import_1" has the same method name "import_import_1" as the import "import-1"`)
+}
+
+func TestRenderLargeImport(t *testing.T) {
+	packages := make([]pipeline.Package, 0, 5000)
+	for n := 0; n < 5000; n++ {
+		packages = append(packages, pipeline.Package{Name: fmt.Sprintf("package%d", n), Version: "1.0.0"})
+	}
+
+	content, err := Backend{}.Render([]pipeline.Import{{Name: "import1", Image: "alpine", Packages: packages}})
+	require.Nil(t, err)
+
+	methods := strings.Split(string(content), "\n\n")
+	pipelineBlock := methods[len(methods)-1]
+	require.True(t, strings.HasPrefix(pipelineBlock, "pipeline {"))
+	require.Contains(t, pipelineBlock, "importPackages('import1', 'alpine', packages_import1_1() + packages_import1_2() + ")
+	require.Contains(t, pipelineBlock, " + packages_import1_11()) { import_import1() }")
+	require.Less(t, len(pipelineBlock), 1024)
+	for _, method := range methods {
+		require.LessOrEqual(t, len(method), dataMethodSize+64)
+	}
+	require.Contains(t, string(content), "        ['PACKAGE_NAME': 'package4999', 'PACKAGE_VERSION': '1.0.0'],\n    ]\n}\n")
+}
+
+func TestRenderTooManyStrings(t *testing.T) {
+	packages := make([]pipeline.Package, 0, maxStrings)
+	for n := 0; n < maxStrings; n++ {
+		packages = append(packages, pipeline.Package{Name: fmt.Sprintf("package%d", n), Version: "1.0.0"})
+	}
+
+	_, err := Backend{}.Render([]pipeline.Import{{Name: "import1", Packages: packages}})
+
+	require.EqualError(t, err, "the package versions have 30003 distinct names and values which is over the limit of 30000 of a Jenkinsfile, split the imports across several configurations")
+}
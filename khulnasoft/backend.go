@@ -0,0 +1,100 @@
+package khulnasoft
+
+import (
+	"fmt"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultFileName = "child_pipeline.yml"
+	shardsStage     = "shards"
+	importsStage    = "imports"
+	shardsJobLabel  = "pkgs_importer_shards"
+	// A parallel matrix generates at most 200 jobs. See https://docs.khulnasoft.com/ee/ci/yaml/#parallelmatrix
+	maxMatrixSize = 200
+)
+
+// Backend renders the imports into a KhulnaSoft child pipeline configuration: a stage and a hidden
+// job with the scripts per import, and a job extending it per package version. With Matrix, an import
+// has a job with a parallel matrix over its package versions instead, much smaller.
+type Backend struct {
+	Matrix bool
+}
+
+// Render returns the KhulnaSoft pipeline configuration of the imports.
+func (b Backend) Render(imports []pipeline.Import) ([]byte, error) {
+	p := newPipeline(len(imports), len(imports))
+
+	for _, i := range imports {
+		p.Stages = append(p.Stages, i.Name)
+
+		if err := p.AddHiddenJob(i.Name, i.Image, i.Scripts); err != nil {
+			return nil, err
+		}
+
+		if b.Matrix {
+			addMatrixJobs(p, i)
+			continue
+		}
+
+		for _, pkg := range i.Packages {
+			p.AddJob(
+				p.withStage(i.Name),
+				p.withImage(i.Image),
+				p.withPackageNameAndVersion(pkg.Name, pkg.Version),
+				p.withAdditionalEnvVariables(pkg.Variables),
+			)
+		}
+	}
+
+	return yaml.Marshal(p)
+}
+
+// addMatrixJobs adds the matrix jobs of an import, with maxMatrixSize package versions at most.
+func addMatrixJobs(p *Pipeline, i pipeline.Import) {
+	matrix := make([]map[string]string, 0, len(i.Packages))
+	for _, pkg := range i.Packages {
+		matrix = append(matrix, p.packageVariables(pkg.Name, pkg.Version, pkg.Variables))
+	}
+
+	chunksCount := (len(matrix) + maxMatrixSize - 1) / maxMatrixSize
+	for n := 0; n < chunksCount; n++ {
+		end := (n + 1) * maxMatrixSize
+		if end > len(matrix) {
+			end = len(matrix)
+		}
+
+		label := i.Name
+		if chunksCount > 1 {
+			label = fmt.Sprintf("%s:%d", i.Name, n+1)
+		}
+		p.AddMatrixJob(label, i.Name, matrix[n*maxMatrixSize:end])
+	}
+}
+
+// MaxSize returns the size limit of a dynamic child pipeline configuration.
+func (b Backend) MaxSize() int64 {
+	return fiveMegaBytes
+}
+
+// DefaultFileName returns child_pipeline.yml.
+func (b Backend) DefaultFileName() string {
+	return defaultFileName
+}
+
+// RenderTrigger returns a parent pipeline configuration with a trigger job per shard. The shards are
+// artifacts of the pkgs_importer_generate job of the upstream pipeline, whose id is in the
+// PARENT_PIPELINE_ID variable: a job gets them and the trigger jobs include them.
+func (b Backend) RenderTrigger(shardPaths []string) ([]byte, error) {
+	p := newPipeline(2, len(shardPaths)+1)
+	p.Stages = append(p.Stages, shardsStage, importsStage)
+	p.addPipelineElement(newArtifactsJob(shardsJobLabel, shardsStage, shardPaths))
+
+	for n, path := range shardPaths {
+		p.addPipelineElement(newTriggerJob(fmt.Sprintf("%s:%d", importsStage, n+1), importsStage, shardsJobLabel, path))
+	}
+
+	return yaml.Marshal(p)
+}
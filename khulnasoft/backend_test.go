@@ -0,0 +1,64 @@
+package khulnasoft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderMatrix(t *testing.T) {
+	packages := func(count int) []pipeline.Package {
+		result := make([]pipeline.Package, 0, count)
+		for n := 0; n < count; n++ {
+			result = append(result, pipeline.Package{Name: "package", Version: fmt.Sprintf("1.0.%d", n), Variables: map[string]string{"PACKAGE_FILE": "file"}})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		packages     []pipeline.Package
+		expectedJobs map[string]int
+	}{
+		{
+			name:         "with a single job",
+			packages:     packages(maxMatrixSize),
+			expectedJobs: map[string]int{"import1": maxMatrixSize},
+		},
+		{
+			name:         "over the matrix size limit",
+			packages:     packages(2*maxMatrixSize + 1),
+			expectedJobs: map[string]int{"import1:1": maxMatrixSize, "import1:2": maxMatrixSize, "import1:3": 1},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			imports := []pipeline.Import{{Name: "import1", Image: "alpine", Scripts: []string{"echo $PACKAGE_FILE"}, Packages: spec.packages}}
+
+			content, err := Backend{Matrix: true}.Render(imports)
+			require.Nil(t, err)
+
+			result := struct {
+				Stages []string
+				Jobs   map[string]struct {
+					Extends  string
+					Parallel Parallel
+				} `yaml:",inline"`
+			}{}
+			require.Nil(t, yaml.Unmarshal(content, &result))
+
+			delete(result.Jobs, ".import1:scripts")
+			require.Len(t, result.Jobs, len(spec.expectedJobs))
+			for label, count := range spec.expectedJobs {
+				require.Equal(t, ".import1:scripts", result.Jobs[label].Extends)
+				require.Len(t, result.Jobs[label].Parallel.Matrix, count)
+				require.Equal(t, "package", result.Jobs[label].Parallel.Matrix[0]["PACKAGE_NAME"])
+				require.Equal(t, "file", result.Jobs[label].Parallel.Matrix[0]["PACKAGE_FILE"])
+			}
+		})
+	}
+}
@@ -1,34 +1,188 @@
 // Package khulnasoft centralizes all the logic and structs needed to transform a configuration
-// into a valid KhulnaSoft pipeline configuration file.
+// into a valid KhulnaSoft pipeline configuration file. Its Generator renders the configuration files
+// of the other CI/CD systems with their pipeline.Backend.
 package khulnasoft
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/logger"
+	"github.com/khulnasoft/packages-registry/pipeline"
 	"github.com/khulnasoft/packages-registry/registry"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
 	"github.com/khulnasoft/packages-registry/util"
-	"gopkg.in/yaml.v3"
 )
 
 // Generator is the builder object that from the configuration will
 // create a CI pipeline configuration file. As such, it has a single
 // exported function: Generate.
 type Generator struct {
-	config *config.Configuration
+	config  *config.Configuration
+	client  *transfer.Client
+	backend pipeline.Backend
 }
 
 const fiveMegaBytes int64 = 5 * 1024 * 1024
 
-// Generate will generate the CI pipeline yaml config file and write it to the
-// passed os.File pointer.
-func (g *Generator) Generate(file *os.File) error {
-	if err := g.generateYamlConfig(file); err != nil {
+// Generate will generate the CI pipeline config file with the backend and write it to the
+// passed os.File pointer. The package name patterns and the version specs, like "@acme/*" and
+// latest:N, are resolved against the source registries and the packages already in the destination
+// of an import with on_existing: skip don't get a job. When the file would be over the limit of the
+// backend, the jobs are split across several files and the passed file runs them.
+func (g *Generator) Generate(ctx context.Context, file *os.File) error {
+	imports, err := g.imports(ctx)
+	if err != nil {
+		return err
+	}
+
+	content, err := g.backend.Render(imports)
+	if err != nil {
+		return err
+	}
+
+	maxSize := g.backend.MaxSize()
+	if sharder, ok := g.backend.(pipeline.Sharder); ok && maxSize != 0 && int64(len(content)) >= maxSize {
+		if content, err = g.shard(sharder, imports, file.Name(), int64(len(content)), maxSize); err != nil {
+			return err
+		}
+	}
+
+	if _, err = file.Write(content); err != nil {
 		return err
 	}
 
-	return g.validate(file, fiveMegaBytes)
+	if maxSize != 0 {
+		return g.validate(file, maxSize)
+	}
+	return nil
+}
+
+// shard splits the package jobs across the smallest number of configuration files under the maximum
+// size and writes them next to the file, like child_pipeline_1.yml and child_pipeline_2.yml for
+// child_pipeline.yml. It returns the configuration file that runs them.
+func (g *Generator) shard(sharder pipeline.Sharder, imports []pipeline.Import, path string, size, maxSize int64) ([]byte, error) {
+	projectPath, err := projectPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	packagesCount := 0
+	for _, i := range imports {
+		packagesCount += len(i.Packages)
+	}
+
+	for n := int(size/maxSize) + 1; n <= packagesCount; n++ {
+		shards, err := renderShards(sharder, split(imports, n), maxSize)
+		if err != nil {
+			return nil, err
+		}
+		if shards == nil {
+			continue
+		}
+
+		paths := make([]string, 0, len(shards))
+		for index, content := range shards {
+			artifactPath := filepath.ToSlash(shardPath(projectPath, index+1))
+			shardPath := shardPath(path, index+1)
+			logger.LogInfo(fmt.Sprintf("Writing pipeline config shard %q", shardPath))
+			if err := os.WriteFile(shardPath, content, 0o644); err != nil {
+				return nil, err
+			}
+			paths = append(paths, artifactPath)
+		}
+
+		return sharder.RenderTrigger(paths)
+	}
+
+	return nil, fmt.Errorf("the generated config file is %d bytes which is over the limit of %d bytes, even with a package per file", size, maxSize)
+}
+
+// projectPath returns the path relative to the working directory, the project directory of the job
+// that generates the file: the artifacts and the includes of the trigger jobs are relative to it. A
+// path outside of the working directory can't be an artifact.
+func projectPath(path string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := filepath.Rel(dir, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the generated config file %q is outside of the project directory %q, the files of a split pipeline must be artifacts of the project: write it under the project directory", path, dir)
+	}
+
+	return relPath, nil
+}
+
+// renderShards renders the shards. It returns nil when a shard is over the maximum size.
+func renderShards(backend pipeline.Backend, shards [][]pipeline.Import, maxSize int64) ([][]byte, error) {
+	contents := make([][]byte, 0, len(shards))
+	for _, shard := range shards {
+		content, err := backend.Render(shard)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(content)) >= maxSize {
+			return nil, nil
+		}
+		contents = append(contents, content)
+	}
+
+	return contents, nil
+}
+
+// split splits the package versions of the imports into n shards of the same size, in order. The
+// packages of an import can be in several shards, each one with its own copy of the import.
+func split(imports []pipeline.Import, n int) [][]pipeline.Import {
+	packagesCount := 0
+	for _, i := range imports {
+		packagesCount += len(i.Packages)
+	}
+	shardSize := (packagesCount + n - 1) / n
+
+	shards := [][]pipeline.Import{{}}
+	count := 0
+	for _, i := range imports {
+		packages := i.Packages
+		for {
+			if count == shardSize && len(packages) != 0 {
+				shards = append(shards, []pipeline.Import{})
+				count = 0
+			}
+
+			end := len(packages)
+			if end > shardSize-count {
+				end = shardSize - count
+			}
+
+			part := i
+			part.Packages = packages[:end]
+			shards[len(shards)-1] = append(shards[len(shards)-1], part)
+			count += end
+			packages = packages[end:]
+
+			if len(packages) == 0 {
+				break
+			}
+		}
+	}
+
+	return shards
+}
+
+// shardPath returns the path of the nth shard: the path with _n before its extension.
+func shardPath(path string, n int) string {
+	extension := filepath.Ext(path)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(path, extension), n, extension)
 }
 
 func (g *Generator) validate(file *os.File, maxSize int64) error {
@@ -38,76 +192,83 @@ func (g *Generator) validate(file *os.File, maxSize int64) error {
 	}
 
 	if stat.Size() >= maxSize {
-		return fmt.Errorf("the generated config file is %d bytes which is over the limit for the KhulnaSoft engine", stat.Size())
+		return fmt.Errorf("the generated config file is %d bytes which is over the limit of %d bytes of the target, split the imports across several configurations", stat.Size(), maxSize)
 	}
 	return nil
 }
 
-func (g *Generator) generateYamlConfig(file *os.File) error {
-	importsCount := len(g.config.Imports)
-	pipeline := newPipeline(importsCount, importsCount)
+// imports returns the imports of the configuration, in the order of their names, with the package
+// versions to copy.
+func (g *Generator) imports(ctx context.Context) ([]pipeline.Import, error) {
+	imports := make([]pipeline.Import, 0, len(g.config.Imports))
 
 	for _, importName := range util.OrderedMapKeysOf(g.config.Imports) {
 		i := g.config.Imports[importName]
-		pipeline.Stages = append(pipeline.Stages, importName)
 
-		registry, err := registry.GetRegistry(i, importName)
+		r, err := registry.GetRegistry(i, importName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		scripts, err := registry.Scripts()
+		scripts, err := r.Scripts()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		image := registry.ImageName()
+		image := r.ImageName()
 
 		if len(i.Image) != 0 {
 			image = i.Image
 		}
 
-		if err := pipeline.AddHiddenJob(importName, image, scripts); err != nil {
-			return err
+		packagesMap, err := config.GetPackagesMap(importName)
+		if err != nil {
+			return nil, err
 		}
 
-		packagesMap, err := config.GetPackagesMap(importName)
+		packagesMap, err = registry.ResolvePackages(ctx, g.client, r, i, importName, packagesMap)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		for _, name := range util.OrderedMapKeysOf(packagesMap) {
-			versions := packagesMap[name]
-			for _, version := range versions {
-				envVars := registry.AdditionalEnvVars(name, version)
-				pipeline.AddJob(
-					pipeline.withStage(importName),
-					pipeline.withImage(image),
-					pipeline.withPackageNameAndVersion(name, version),
-					pipeline.withAdditionalEnvVariables(envVars),
-				)
-			}
+		pipelineImport := pipeline.Import{
+			Name:    importName,
+			Image:   image,
+			Scripts: scripts,
 		}
-	}
 
-	return g.marshalYaml(file, pipeline)
-}
+		for _, name := range util.OrderedMapKeysOf(packagesMap) {
+			for _, version := range packagesMap[name] {
+				ok, err := registry.ShouldImport(ctx, g.client, r, i, importName, name, version)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					logger.LogInfo(fmt.Sprintf("Skipping %s %s of import %q: it already exists in the destination", name, version, importName))
+					continue
+				}
 
-func (g *Generator) marshalYaml(file *os.File, pipeline *Pipeline) error {
-	buff, err := yaml.Marshal(pipeline)
-	if err != nil {
-		return err
-	}
+				pipelineImport.Packages = append(pipelineImport.Packages, pipeline.Package{
+					Name:      name,
+					Version:   version,
+					Variables: r.AdditionalEnvVars(name, version),
+				})
+			}
+		}
 
-	if _, err = file.WriteString(string(buff)); err != nil {
-		return err
+		imports = append(imports, pipelineImport)
 	}
 
-	return nil
+	return imports, nil
 }
 
-func NewGenerator(config *config.Configuration) *Generator {
+// NewGenerator will create a new Generator for the configuration. The client looks up the packages
+// already in the destinations of the imports with an on_existing value and the backend renders the
+// configuration file of the CI/CD system.
+func NewGenerator(config *config.Configuration, client *transfer.Client, backend pipeline.Backend) *Generator {
 	return &Generator{
-		config: config,
+		config:  config,
+		client:  client,
+		backend: backend,
 	}
 }
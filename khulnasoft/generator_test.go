@@ -1,12 +1,17 @@
 package khulnasoft
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/tekton"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
@@ -109,7 +114,7 @@ func TestGeneratePipelineConfig(t *testing.T) {
 	for _, spec := range tests {
 		t.Run(spec.name, func(t *testing.T) {
 			t.Cleanup(viper.Reset)
-			g := NewGenerator(configFrom(spec.imports))
+			g := NewGenerator(configFrom(spec.imports), transfer.NewClient(nil), Backend{})
 
 			file, err := os.CreateTemp(os.TempDir(), "output*.yml")
 			if err != nil {
@@ -119,7 +124,7 @@ func TestGeneratePipelineConfig(t *testing.T) {
 			defer file.Close()
 			defer os.Remove(file.Name())
 
-			err = g.Generate(file)
+			err = g.Generate(context.Background(), file)
 			require.Nil(t, err)
 
 			require.FileExists(t, file.Name())
@@ -212,3 +217,140 @@ func configFrom(imports []testImport) *config.Configuration {
 		Imports: configImports,
 	}
 }
+
+// limitedBackend is the KhulnaSoft backend with a lower size limit.
+type limitedBackend struct {
+	Backend
+	maxSize int64
+}
+
+func (b limitedBackend) MaxSize() int64 {
+	return b.maxSize
+}
+
+func TestGenerateShards(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	g := NewGenerator(configFrom(multipleImports), transfer.NewClient(nil), limitedBackend{maxSize: 1500})
+
+	projectDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.Nil(t, err)
+	chdir(t, projectDir)
+	dir := filepath.Join(projectDir, "imports")
+	require.Nil(t, os.Mkdir(dir, 0o755))
+	file, err := os.Create(filepath.Join(dir, "child_pipeline.yml"))
+	require.Nil(t, err)
+	defer file.Close()
+
+	err = g.Generate(context.Background(), file)
+	require.Nil(t, err)
+
+	trigger, err := os.ReadFile(file.Name())
+	require.Nil(t, err)
+
+	shards := ""
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("child_pipeline_%d.yml", n))
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			require.Greater(t, n, 2)
+			require.NotContains(t, string(trigger), fmt.Sprintf("imports:%d:", n))
+			break
+		}
+		require.Nil(t, err)
+		require.Less(t, len(content), 1500)
+
+		require.Contains(t, string(trigger), fmt.Sprintf("imports:%d:", n))
+		require.Contains(t, string(trigger), fmt.Sprintf("- artifact: imports/child_pipeline_%d.yml\n", n))
+		shards += string(content)
+	}
+
+	require.Contains(t, string(trigger), "job: pkgs_importer_generate")
+	require.Contains(t, string(trigger), "pipeline: $PARENT_PIPELINE_ID")
+	assertContainsPackages(t, shards, multipleImports)
+}
+
+func TestGenerateShardsOutsideOfTheProject(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	g := NewGenerator(configFrom(multipleImports), transfer.NewClient(nil), limitedBackend{maxSize: 1500})
+
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.Nil(t, err)
+	projectDir := filepath.Join(tempDir, "project")
+	require.Nil(t, os.Mkdir(projectDir, 0o755))
+	chdir(t, projectDir)
+	file, err := os.Create("../child_pipeline.yml")
+	require.Nil(t, err)
+	defer file.Close()
+
+	err = g.Generate(context.Background(), file)
+	require.EqualError(t, err, fmt.Sprintf("the generated config file %q is outside of the project directory %q, the files of a split pipeline must be artifacts of the project: write it under the project directory", "../child_pipeline.yml", projectDir))
+	require.NoFileExists(t, filepath.Join(projectDir, "..", "child_pipeline_1.yml"))
+}
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	t.Cleanup(func() { require.Nil(t, os.Chdir(wd)) })
+}
+
+func TestGenerateOverTheLimit(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	packages := map[string]string{}
+	for n := 0; n < 7000; n++ {
+		packages[fmt.Sprintf("package%d", n)] = "1.0.0"
+	}
+	imports := []testImport{{Name: "import1", Packages: packages, Import: singleImport[0].Import}}
+	g := NewGenerator(configFrom(imports), transfer.NewClient(nil), tekton.Backend{})
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "import_packages.yaml"))
+	require.Nil(t, err)
+	defer file.Close()
+
+	err = g.Generate(context.Background(), file)
+	require.ErrorContains(t, err, "over the limit of 1572864 bytes of the target, split the imports across several configurations")
+}
+
+func TestSplit(t *testing.T) {
+	packages := func(names ...string) []pipeline.Package {
+		result := []pipeline.Package{}
+		for _, name := range names {
+			result = append(result, pipeline.Package{Name: name, Version: "1.0.0"})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		imports  []pipeline.Import
+		n        int
+		expected [][]pipeline.Import
+	}{
+		{
+			name:     "in a single shard",
+			imports:  []pipeline.Import{{Name: "import1", Packages: packages("a", "b")}},
+			n:        1,
+			expected: [][]pipeline.Import{{{Name: "import1", Packages: packages("a", "b")}}},
+		},
+		{
+			name: "with an import across shards",
+			imports: []pipeline.Import{
+				{Name: "import1", Packages: packages("a", "b", "c")},
+				{Name: "import2"},
+				{Name: "import3", Packages: packages("d")},
+			},
+			n: 2,
+			expected: [][]pipeline.Import{
+				{{Name: "import1", Packages: packages("a", "b")}},
+				{{Name: "import1", Packages: packages("c")}, {Name: "import2"}, {Name: "import3", Packages: packages("d")}},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			require.Equal(t, spec.expected, split(spec.imports, spec.n))
+		})
+	}
+}
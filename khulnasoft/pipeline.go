@@ -56,6 +56,12 @@ func (p *Pipeline) AddJob(options ...func(*standardJob)) {
 	p.addPipelineElement(element)
 }
 
+// AddMatrixJob adds a job that runs the scripts of a stage once per entry of the matrix, with the
+// environment variables of the entry. See https://docs.khulnasoft.com/ee/ci/yaml/#parallelmatrix
+func (p *Pipeline) AddMatrixJob(label, stage string, matrix []map[string]string) {
+	p.addPipelineElement(newMatrixJob(label, p.hiddenJobLabel(stage), matrix))
+}
+
 func (p *Pipeline) withStage(stage string) func(*standardJob) {
 	return func(s *standardJob) {
 		s.stage = stage
@@ -128,6 +134,32 @@ func newJob(label, extends string, variables map[string]string) Job {
 	}
 }
 
+// MatrixJob represents a job for a KhulnaSoft pipeline that runs the scripts it extends for several
+// packages, each one with the environment variables of a matrix entry.
+type MatrixJob struct {
+	label    string
+	Extends  string
+	Parallel Parallel
+}
+
+// Parallel represents the parallel matrix of a job.
+type Parallel struct {
+	Matrix []map[string]string
+}
+
+// Label returns the matrix job's label.
+func (j MatrixJob) Label() string {
+	return j.label
+}
+
+func newMatrixJob(label, extends string, matrix []map[string]string) MatrixJob {
+	return MatrixJob{
+		label:    label,
+		Extends:  extends,
+		Parallel: Parallel{Matrix: matrix},
+	}
+}
+
 // HiddenJob represents a hidden job for a KhulnaSoft pipeline. See https://docs.khulnasoft.com/ee/ci/jobs/#hide-jobs.
 // Hidden jobs will mainly host the scripts lines that need to be executed to import a package.
 type HiddenJob struct {
@@ -156,3 +188,81 @@ func newHiddenJob(label, stage, image string, scripts []string) (HiddenJob, erro
 		Scripts: scripts,
 	}, nil
 }
+
+// generateJobLabel is the label of the job that generates the pipeline configuration files, in the
+// templates/gitlab/.gitlab-ci.yml template.
+const generateJobLabel = "pkgs_importer_generate"
+
+// ArtifactsJob represents a job that gets the pipeline configuration files generated in the
+// upstream pipeline and exposes them as its own artifacts, so that trigger jobs can include them.
+// See https://docs.khulnasoft.com/ee/ci/yaml/#needspipelinejob
+type ArtifactsJob struct {
+	label     string
+	Image     string
+	Stage     string
+	Needs     []map[string]string
+	Scripts   []string `yaml:"script"`
+	Artifacts Artifacts
+}
+
+// Artifacts represents the artifacts of a job.
+type Artifacts struct {
+	Paths []string
+}
+
+// Label returns the artifacts job's label.
+func (j ArtifactsJob) Label() string {
+	return j.label
+}
+
+func newArtifactsJob(label, stage string, paths []string) ArtifactsJob {
+	return ArtifactsJob{
+		label: label,
+		Image: "alpine:latest",
+		Stage: stage,
+		Needs: []map[string]string{
+			{"pipeline": "$PARENT_PIPELINE_ID", "job": generateJobLabel},
+		},
+		Scripts:   []string{"ls -l " + strings.Join(paths, " ")},
+		Artifacts: Artifacts{Paths: paths},
+	}
+}
+
+// TriggerJob represents a job that triggers a child pipeline with the configuration file of an
+// artifact. See https://docs.khulnasoft.com/ee/ci/yaml/#triggerinclude
+type TriggerJob struct {
+	label   string
+	Stage   string
+	Needs   []string
+	Trigger Trigger
+}
+
+// Trigger represents the child pipeline of a trigger job. The depend strategy makes the trigger job
+// fail when the child pipeline fails.
+type Trigger struct {
+	Include  []IncludeArtifact
+	Strategy string
+}
+
+// IncludeArtifact represents a configuration file that is an artifact of a job.
+type IncludeArtifact struct {
+	Artifact string
+	Job      string
+}
+
+// Label returns the trigger job's label.
+func (j TriggerJob) Label() string {
+	return j.label
+}
+
+func newTriggerJob(label, stage, artifactsJob, path string) TriggerJob {
+	return TriggerJob{
+		label: label,
+		Stage: stage,
+		Needs: []string{artifactsJob},
+		Trigger: Trigger{
+			Include:  []IncludeArtifact{{Artifact: path, Job: artifactsJob}},
+			Strategy: "depend",
+		},
+	}
+}
@@ -0,0 +1,100 @@
+// Package pipeline describes the jobs of the imports independently of the CI/CD system that runs
+// them. A Backend renders them into the configuration file of a CI/CD system.
+package pipeline
+
+import (
+	"regexp"
+
+	"github.com/khulnasoft/packages-registry/util"
+)
+
+var (
+	variableRegexp   = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]*)`)
+	assignmentRegexp = regexp.MustCompile(`(?:^|[\s;&|(])([A-Z][A-Z0-9_]*)=`)
+)
+
+// Import is an import of the configuration: the scripts that copy a package, the image they run
+// in and the package versions to copy.
+type Import struct {
+	Name     string
+	Image    string
+	Scripts  []string
+	Packages []Package
+}
+
+// Package is a package version to copy. Variables are the environment variables the scripts need on
+// top of PACKAGE_NAME and PACKAGE_VERSION.
+type Package struct {
+	Name      string
+	Version   string
+	Variables map[string]string
+}
+
+// Backend renders the imports into the configuration file of a CI/CD system.
+type Backend interface {
+	// Render returns the content of the configuration file.
+	Render(imports []Import) ([]byte, error)
+	// MaxSize returns the maximum size of the configuration file, in bytes, or 0 without limit.
+	MaxSize() int64
+	// DefaultFileName returns the name of the configuration file when none is given.
+	DefaultFileName() string
+}
+
+// Sharder is a Backend that splits the jobs across several configuration files, the shards, when
+// the configuration file is over its maximum size.
+type Sharder interface {
+	Backend
+	// RenderTrigger returns the content of the configuration file that runs the shards.
+	RenderTrigger(shardPaths []string) ([]byte, error)
+}
+
+// AllVariables returns the environment variables of the package job: PACKAGE_NAME, PACKAGE_VERSION and
+// the additional variables.
+func (p Package) AllVariables() map[string]string {
+	vars := map[string]string{
+		"PACKAGE_NAME":    p.Name,
+		"PACKAGE_VERSION": p.Version,
+	}
+	for key, value := range p.Variables {
+		vars[key] = value
+	}
+	return vars
+}
+
+// VariableNames returns the names of the environment variables of the packages, sorted.
+func (i Import) VariableNames() []string {
+	names := map[string]struct{}{}
+	for _, p := range i.Packages {
+		for name := range p.AllVariables() {
+			names[name] = struct{}{}
+		}
+	}
+
+	return util.OrderedMapKeysOf(names)
+}
+
+// Secrets returns the upper case environment variables used in the scripts that are neither set by
+// the scripts nor variables of the packages, like $DESTINATION_TOKEN in a token. The CI/CD system
+// provides them, sorted.
+func (i Import) Secrets() []string {
+	set := map[string]struct{}{}
+	for _, name := range i.VariableNames() {
+		set[name] = struct{}{}
+	}
+	for _, script := range i.Scripts {
+		for _, match := range assignmentRegexp.FindAllStringSubmatch(script, -1) {
+			set[match[1]] = struct{}{}
+		}
+	}
+
+	used := map[string]struct{}{}
+	for _, script := range i.Scripts {
+		for _, match := range variableRegexp.FindAllStringSubmatch(script, -1) {
+			if _, ok := set[match[1]]; !ok {
+				used[match[1]] = struct{}{}
+			}
+		}
+	}
+
+	return util.OrderedMapKeysOf(used)
+}
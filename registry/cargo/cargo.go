@@ -0,0 +1,104 @@
+// Package cargo is a set of simple functions that provide all the custom parts to handle
+// Cargo (Rust) crate imports from registry A to registry B.
+package cargo
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Cargo registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Cargo registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validatePackages(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Cargo crate imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The Cargo scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+// importScript resolves the crate in the source sparse index, downloads the .crate file and
+// publishes it with the destination publish API. See cargo_import.py for the details.
+//
+//go:embed cargo_import.py
+var importScript string
+
+const importScriptFile = "cargo_import.py"
+
+// Scripts returns the script lines to execute a Cargo crate import.
+// The cargo CLI can't publish an existing .crate file, so the registries are accessed directly:
+// - https://doc.rust-lang.org/cargo/reference/registry-index.html#sparse-protocol
+// - https://doc.rust-lang.org/cargo/reference/registry-web-api.html#publish
+func (r *Registry) Scripts() ([]string, error) {
+	return []string{
+		fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript),
+		fmt.Sprintf(`export CARGO_SOURCE_TOKEN="%s"`, r.pkgsImport.Source.Credentials.Token),
+		fmt.Sprintf(`export CARGO_DESTINATION_TOKEN="%s"`, r.pkgsImport.Destination.Credentials.Token),
+		fmt.Sprintf(`python3 %s %s %s "$PACKAGE_NAME" "$PACKAGE_VERSION"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL),
+	}, nil
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+const maxCrateNameLength = 64
+
+// See https://doc.rust-lang.org/cargo/reference/manifest.html#the-name-field
+var crateNameRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+func (r *Registry) validatePackageName(name string) error {
+	if len(name) > maxCrateNameLength || !crateNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid crate name. It must start with a letter, only contain alphanumeric characters, - or _ and be at most %d characters long.", name, maxCrateNameLength)
+	}
+	return nil
+}
+
+// See https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string
+var crateVersionRegexp = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !crateVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid crate version. It must be a semantic version like 1.2.3.", version)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+"""Copies a crate version from a sparse index registry to another one.
+
+Usage: python3 cargo_import.py <source index url> <destination index url> <name> <version>
+
+Tokens are read from the CARGO_SOURCE_TOKEN and CARGO_DESTINATION_TOKEN environment variables.
+See https://doc.rust-lang.org/cargo/reference/registries.html.
+"""
+
+import hashlib
+import io
+import json
+import os
+import struct
+import sys
+import tarfile
+import tomllib
+import urllib.request
+
+DL_MARKERS = ("{crate}", "{version}", "{prefix}", "{lowerprefix}", "{sha256-checksum}")
+
+
+def request(url, token, data=None, method="GET"):
+    headers = {"User-Agent": "pkgs_importer"}
+    if token:
+        headers["Authorization"] = token
+    req = urllib.request.Request(url, data=data, headers=headers, method=method)
+    with urllib.request.urlopen(req) as response:
+        return response.read()
+
+
+def index_url(url):
+    return url.removeprefix("sparse+").rstrip("/")
+
+
+def prefix(name):
+    if len(name) <= 2:
+        return str(len(name))
+    if len(name) == 3:
+        return "3/" + name[0]
+    return name[0:2] + "/" + name[2:4]
+
+
+def find_entry(index, token, name, version):
+    lines = request("%s/%s/%s" % (index, prefix(name.lower()), name.lower()), token).decode()
+    for line in lines.splitlines():
+        if line.strip():
+            entry = json.loads(line)
+            if entry["vers"] == version:
+                return entry
+    sys.exit("version %s of crate %s not found in %s" % (version, name, index))
+
+
+def download_url(dl, name, version, checksum):
+    if not any(marker in dl for marker in DL_MARKERS):
+        return "%s/%s/%s/download" % (dl.rstrip("/"), name, version)
+    return (
+        dl.replace("{crate}", name)
+        .replace("{version}", version)
+        .replace("{prefix}", prefix(name))
+        .replace("{lowerprefix}", prefix(name.lower()))
+        .replace("{sha256-checksum}", checksum)
+    )
+
+
+def manifest(crate, name, version):
+    with tarfile.open(fileobj=io.BytesIO(crate), mode="r:gz") as archive:
+        root = "%s-%s/" % (name, version)
+        package = tomllib.load(archive.extractfile(root + "Cargo.toml"))["package"]
+        readme = None
+        readme_file = package.get("readme")
+        if isinstance(readme_file, str):
+            try:
+                readme = archive.extractfile(root + readme_file).read().decode()
+            except KeyError:
+                readme = None
+    return package, readme, readme_file
+
+
+def publish_metadata(entry, package, readme, readme_file):
+    features = dict(entry.get("features", {}))
+    features.update(entry.get("features2", {}))
+    deps = []
+    for dep in entry.get("deps", []):
+        deps.append(
+            {
+                "name": dep.get("package") or dep["name"],
+                "version_req": dep["req"],
+                "features": dep.get("features", []),
+                "optional": dep.get("optional", False),
+                "default_features": dep.get("default_features", True),
+                "target": dep.get("target"),
+                "kind": dep.get("kind", "normal"),
+                "registry": dep.get("registry"),
+                "explicit_name_in_toml": dep["name"] if dep.get("package") else None,
+            }
+        )
+    return {
+        "name": entry["name"],
+        "vers": entry["vers"],
+        "deps": deps,
+        "features": features,
+        "authors": package.get("authors", []),
+        "description": package.get("description"),
+        "documentation": package.get("documentation"),
+        "homepage": package.get("homepage"),
+        "readme": readme,
+        "readme_file": readme_file if isinstance(readme_file, str) else None,
+        "keywords": package.get("keywords", []),
+        "categories": package.get("categories", []),
+        "license": package.get("license"),
+        "license_file": package.get("license-file"),
+        "repository": package.get("repository"),
+        "badges": {},
+        "links": entry.get("links"),
+        "rust_version": entry.get("rust_version"),
+    }
+
+
+def main(source, destination, name, version):
+    source_token = os.environ.get("CARGO_SOURCE_TOKEN")
+    destination_token = os.environ.get("CARGO_DESTINATION_TOKEN")
+    source, destination = index_url(source), index_url(destination)
+
+    source_config = json.loads(request(source + "/config.json", source_token))
+    entry = find_entry(source, source_token, name, version)
+    name = entry["name"]
+
+    crate = request(download_url(source_config["dl"], name, version, entry["cksum"]), source_token)
+    if hashlib.sha256(crate).hexdigest() != entry["cksum"]:
+        sys.exit("checksum mismatch for crate %s version %s" % (name, version))
+
+    metadata = json.dumps(publish_metadata(entry, *manifest(crate, name, version))).encode()
+    body = struct.pack("<I", len(metadata)) + metadata + struct.pack("<I", len(crate)) + crate
+
+    destination_config = json.loads(request(destination + "/config.json", destination_token))
+    api = destination_config["api"].rstrip("/")
+    print(request(api + "/api/v1/crates/new", destination_token, data=body, method="PUT").decode())
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:5])
@@ -0,0 +1,115 @@
+package cargo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "sparse+https://source.test/index/",
+			Credentials: config.Credentials{
+				Token: "TOKEN_FOR_SOURCE",
+			},
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/index",
+			Credentials: config.Credentials{
+				Token: "TOKEN_FOR_DESTINATION",
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 4)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > cargo_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `"/api/v1/crates/new"`)
+	require.Equal(t, `export CARGO_SOURCE_TOKEN="TOKEN_FOR_SOURCE"`, scripts[1])
+	require.Equal(t, `export CARGO_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`, scripts[2])
+	require.Equal(t, `python3 cargo_import.py sparse+https://source.test/index/ https://destination.test/index "$PACKAGE_NAME" "$PACKAGE_VERSION"`, scripts[3])
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with no packages",
+		},
+		{
+			name: "with valid crates",
+			pkgs: map[string][]string{
+				"serde":        {"1.0.188"},
+				"tokio-util_2": {"0.7.0-alpha.1", "0.7.9+build.5"},
+			},
+		},
+		{
+			name:         "with crate name starting with a digit",
+			errorMessage: "2d is an invalid crate name. It must start with a letter, only contain alphanumeric characters, - or _ and be at most 64 characters long.",
+			pkgs: map[string][]string{
+				"2d": {"1.0.0"},
+			},
+		},
+		{
+			name:         "with crate name with invalid characters",
+			errorMessage: "my.crate is an invalid crate name.",
+			pkgs: map[string][]string{
+				"my.crate": {"1.0.0"},
+			},
+		},
+		{
+			name:         "with crate name too long",
+			errorMessage: "is an invalid crate name.",
+			pkgs: map[string][]string{
+				strings.Repeat("a", 65): {"1.0.0"},
+			},
+		},
+		{
+			name:         "with invalid version",
+			errorMessage: "1.0 is an invalid crate version. It must be a semantic version like 1.2.3.",
+			pkgs: map[string][]string{
+				"serde": {"1.0"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(config.Import{Type: "cargo"}, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{}, new(Registry).AdditionalEnvVars("name", "version"))
+}
@@ -0,0 +1,152 @@
+// Package composer is a set of simple functions that provide all the custom parts to handle
+// Composer (PHP) package imports from registry A to registry B.
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Composer registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Composer registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Composer package imports.
+func (r *Registry) ImageName() string {
+	return "composer:latest"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The Composer scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+const (
+	// Reads packages.json and prints the metadata url of $PACKAGE_NAME. The metadata-url can be relative to the host.
+	// See https://getcomposer.org/doc/05-repositories.md#metadata-url
+	metadataUrlPhpScript = `$r = json_decode(file_get_contents("packages.json"), true); $u = str_replace("%package%", getenv("PACKAGE_NAME"), $r["metadata-url"] ?? "/p2/%package%.json"); if (parse_url($u, PHP_URL_SCHEME) === null) { $s = parse_url(getenv("SOURCE_URL")); $u = $s["scheme"] . "://" . $s["host"] . (isset($s["port"]) ? ":" . $s["port"] : "") . $u; } echo $u;`
+	// Reads the package metadata and prints the zip dist url of $PACKAGE_VERSION.
+	distUrlPhpScript = `$m = json_decode(file_get_contents("metadata.json"), true); foreach ($m["packages"][getenv("PACKAGE_NAME")] ?? [] as $p) { if (ltrim($p["version"], "v") === ltrim(getenv("PACKAGE_VERSION"), "v")) { if (($p["dist"]["type"] ?? "") !== "zip") { fwrite(STDERR, "only zip dist archives are supported\n"); exit(1); } echo $p["dist"]["url"]; exit(0); } } fwrite(STDERR, "version not found\n"); exit(1);`
+)
+
+// Scripts returns the script lines to execute a Composer package import.
+// The source repository metadata is read to find the dist archive of the package version. See
+// https://getcomposer.org/doc/05-repositories.md#composer. The dist archive is then downloaded
+// and uploaded to the destination registry API with curl.
+func (r *Registry) Scripts() ([]string, error) {
+	source := r.pkgsImport.Source
+	sourceUrl := strings.TrimSuffix(source.URL, "/")
+	sourceAuth := r.authOption(source.Credentials)
+
+	return []string{
+		"mkdir _pkg",
+		"cd _pkg",
+		fmt.Sprintf(`export SOURCE_URL="%s"`, sourceUrl),
+		fmt.Sprintf(`curl --fail --silent --show-error --location%s --output packages.json "$SOURCE_URL/packages.json"`, sourceAuth),
+		fmt.Sprintf(`metadata_url=$(php -r '%s')`, metadataUrlPhpScript),
+		fmt.Sprintf(`curl --fail --silent --show-error --location%s --output metadata.json "$metadata_url"`, sourceAuth),
+		fmt.Sprintf(`dist_url=$(php -r '%s')`, distUrlPhpScript),
+		r.downloadDistScript(sourceAuth),
+		r.pushScript(),
+	}, nil
+}
+
+// downloadDistScript downloads the dist archive. Dist archives can be hosted outside of the
+// source registry. In that case, the credentials are not sent.
+func (r *Registry) downloadDistScript(sourceAuth string) string {
+	download := `curl --fail --silent --show-error --location%s --output dist.zip "$dist_url"`
+
+	if len(sourceAuth) == 0 {
+		return fmt.Sprintf(download, "")
+	}
+
+	return fmt.Sprintf(`if [ "${dist_url#$SOURCE_URL}" != "$dist_url" ]; then %s; else %s; fi`, fmt.Sprintf(download, sourceAuth), fmt.Sprintf(download, ""))
+}
+
+// pushScript uploads the dist archive to the destination registry API with a multipart/form-data
+// POST request, with the package, name and version fields. It's the upload of the KhulnaSoft
+// project Composer API, <khulnasoft_url>/api/v4/projects/<project_id>/packages/composer.
+func (r *Registry) pushScript() string {
+	destination := r.pkgsImport.Destination
+
+	return fmt.Sprintf(`curl --fail --silent --show-error%s --form "package=@dist.zip" --form "name=$PACKAGE_NAME" --form "version=$PACKAGE_VERSION" "%s"`, r.authOption(destination.Credentials), destination.URL)
+}
+
+func (r *Registry) authOption(credentials config.Credentials) string {
+	if len(credentials.Token) == 0 {
+		return ""
+	}
+
+	if username := credentials.AdditionalParameters["username"]; len(username) != 0 {
+		return fmt.Sprintf(` --user "%s:%s"`, username, credentials.Token)
+	}
+
+	return fmt.Sprintf(` --header "%s: %s"`, credentials.AdditionalParameters["header_name"], credentials.Token)
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// See https://getcomposer.org/doc/04-schema.md#name
+var composerVendorAndNameRegexp = regexp.MustCompile(`^[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]|-{1,2})?[a-z0-9]+)*$`)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !composerVendorAndNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid Composer package name. It must be lower case and contain / between the vendor name and the project name.", name)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("Composer credentials require a token and a username or a token and a header_name for authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if credentials.Token == "" || credentials.AdditionalParameters["username"] != "" || credentials.AdditionalParameters["header_name"] != "" {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,282 @@
+package composer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		name                        string
+		sourceUrl                   string
+		sourceToken                 string
+		sourceAdditionalParams      map[string]string
+		destinationUrl              string
+		destinationToken            string
+		destinationAdditionalParams map[string]string
+		expectedSourceAuth          string
+		expectedDestinationAuth     string
+	}{
+		{
+			name:                        "public source, destination with header and token",
+			sourceUrl:                   "http://source.test/",
+			destinationUrl:              "https://destination.test",
+			destinationToken:            "TOKEN_FOR_DESTINATION",
+			destinationAdditionalParams: map[string]string{"header_name": "Private-Token"},
+			expectedDestinationAuth:     ` --header "Private-Token: TOKEN_FOR_DESTINATION"`,
+		},
+		{
+			name:                        "source with username and token, destination with username and token",
+			sourceUrl:                   "http://source.test",
+			sourceToken:                 "TOKEN_FOR_SOURCE",
+			sourceAdditionalParams:      map[string]string{"username": "USER_FOR_SOURCE"},
+			destinationUrl:              "https://destination.test",
+			destinationToken:            "TOKEN_FOR_DESTINATION",
+			destinationAdditionalParams: map[string]string{"username": "USER_FOR_DESTINATION"},
+			expectedSourceAuth:          ` --user "USER_FOR_SOURCE:TOKEN_FOR_SOURCE"`,
+			expectedDestinationAuth:     ` --user "USER_FOR_DESTINATION:TOKEN_FOR_DESTINATION"`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: spec.sourceAdditionalParams,
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: spec.destinationAdditionalParams,
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+
+			require.Contains(t, scripts, `export SOURCE_URL="http://source.test"`)
+			require.Contains(t, scripts, fmt.Sprintf(`curl --fail --silent --show-error --location%s --output packages.json "$SOURCE_URL/packages.json"`, spec.expectedSourceAuth))
+			require.Contains(t, scripts, fmt.Sprintf(`curl --fail --silent --show-error --location%s --output metadata.json "$metadata_url"`, spec.expectedSourceAuth))
+			require.Contains(t, scripts, fmt.Sprintf(`curl --fail --silent --show-error%s --form "package=@dist.zip" --form "name=$PACKAGE_NAME" --form "version=$PACKAGE_VERSION" "%s"`, spec.expectedDestinationAuth, spec.destinationUrl))
+
+			joinedScripts := strings.Join(scripts, "\n")
+			if len(spec.expectedSourceAuth) != 0 {
+				require.Contains(t, joinedScripts, `if [ "${dist_url#$SOURCE_URL}" != "$dist_url" ]; then`)
+			} else {
+				require.Contains(t, scripts, `curl --fail --silent --show-error --location --output dist.zip "$dist_url"`)
+			}
+		})
+	}
+}
+
+func TestPushScript(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is needed to run the script")
+	}
+
+	tests := []struct {
+		name           string
+		status         int
+		expectedFailed bool
+	}{
+		{
+			name:   "package uploaded",
+			status: http.StatusCreated,
+		},
+		{
+			name:           "package rejected",
+			status:         http.StatusUnprocessableEntity,
+			expectedFailed: true,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			// Mimics the KhulnaSoft project Composer API.
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/api/v4/projects/1/packages/composer", r.URL.Path)
+				assert.Equal(t, "TOKEN_FOR_DESTINATION", r.Header.Get("Private-Token"))
+
+				file, header, err := r.FormFile("package")
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer file.Close()
+				content, err := io.ReadAll(file)
+				assert.NoError(t, err)
+				assert.Equal(t, "dist archive", string(content))
+				assert.Equal(t, "dist.zip", header.Filename)
+				assert.Equal(t, "my-vendor/my-package", r.FormValue("name"))
+				assert.Equal(t, "1.2.3", r.FormValue("version"))
+
+				w.WriteHeader(spec.status)
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Destination: config.Registry{
+						URL: destination.URL + "/api/v4/projects/1/packages/composer",
+						Credentials: config.Credentials{
+							Token:                "TOKEN_FOR_DESTINATION",
+							AdditionalParameters: map[string]string{"header_name": "Private-Token"},
+						},
+					},
+				},
+			}
+
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "dist.zip"), []byte("dist archive"), 0o600))
+
+			cmd := exec.Command("sh", "-c", registry.pushScript())
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "PACKAGE_NAME=my-vendor/my-package", "PACKAGE_VERSION=1.2.3")
+			output, err := cmd.CombinedOutput()
+
+			if spec.expectedFailed {
+				require.Error(t, err)
+				require.Contains(t, string(output), "422")
+			} else {
+				require.NoError(t, err, string(output))
+			}
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"username": "user",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "composer",
+			},
+		},
+		{
+			name: "with registries with correct credentials and packages",
+			pkgsImport: config.Import{
+				Type:        "composer",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my-vendor/my-package":   {"1.2.3"},
+				"vendor/package.name_01": {"v2.0.0"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "composer",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "composer",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with package with no vendor",
+			errorMessage: "my-package is an invalid Composer package name. It must be lower case and contain / between the vendor name and the project name.",
+			pkgsImport: config.Import{
+				Type:        "composer",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my-package": {"1.2.3"},
+			},
+		},
+		{
+			name:         "with package with upper case letters",
+			errorMessage: "Vendor/Package is an invalid Composer package name. It must be lower case and contain / between the vendor name and the project name.",
+			pkgsImport: config.Import{
+				Type:        "composer",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"Vendor/Package": {"1.2.3"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "composer:latest", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{}, new(Registry).AdditionalEnvVars("name", "version"))
+}
@@ -0,0 +1,140 @@
+// Package conan is a set of simple functions that provide all the custom parts to handle
+// Conan (C/C++) package imports from registry A to registry B.
+package conan
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Conan 2 remote given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Conan registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Conan package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// Conan references are in the form of name/version@user/channel#revision.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{"PACKAGE_REFERENCE": reference(name, version)}
+}
+
+func reference(name, version string) string {
+	return fmt.Sprintf("%s/%s", name, version)
+}
+
+const (
+	sourceRegistryLabel      = "pkgs_importer_source"
+	destinationRegistryLabel = "pkgs_importer_destination"
+)
+
+// Scripts returns the script lines to execute a Conan package import.
+// The recipe and all its binaries are downloaded in the local cache and then uploaded. See:
+// - https://docs.conan.io/2/reference/commands/remote.html
+// - https://docs.conan.io/2/reference/commands/download.html
+// - https://docs.conan.io/2/reference/commands/upload.html
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 8)
+
+	scripts = append(scripts, "python -m pip install conan")
+	scripts = append(scripts, r.removeDefaults())
+	scripts = append(scripts, r.configureAccess(r.pkgsImport.Source, sourceRegistryLabel)...)
+	scripts = append(scripts, r.downloadScript(sourceRegistryLabel))
+	scripts = append(scripts, r.configureAccess(r.pkgsImport.Destination, destinationRegistryLabel)...)
+	scripts = append(scripts, r.uploadScript(destinationRegistryLabel))
+
+	return scripts, nil
+}
+
+func (r *Registry) removeDefaults() string {
+	return "conan remote remove conancenter"
+}
+
+func (r *Registry) configureAccess(registry config.Registry, label string) []string {
+	scripts := []string{fmt.Sprintf(`conan remote add %s "%s"`, label, registry.URL)}
+
+	if len(registry.Credentials.Token) != 0 {
+		scripts = append(scripts, fmt.Sprintf(`conan remote login %s "%s" --password "%s"`, label, registry.Credentials.AdditionalParameters["username"], registry.Credentials.Token))
+	}
+
+	return scripts
+}
+
+func (r *Registry) downloadScript(label string) string {
+	return fmt.Sprintf(`conan download "$PACKAGE_REFERENCE" --remote %s`, label)
+}
+
+func (r *Registry) uploadScript(label string) string {
+	return fmt.Sprintf(`conan upload "$PACKAGE_REFERENCE" --remote %s --confirm`, label)
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		for _, packageVersion := range packageVersions {
+			if err := r.validateReference(reference(packageName, packageVersion)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// The recipe revision is optional. It can be a revision hash, latest or * for all the revisions.
+// See https://docs.conan.io/2/tutorial/versioning/revisions.html
+var conanReferenceRegexp = regexp.MustCompile(`^[a-z0-9_][a-z0-9_+.-]*/[a-zA-Z0-9_][a-zA-Z0-9_+.-]*(@[a-zA-Z0-9_][a-zA-Z0-9_+.-]*/[a-zA-Z0-9_][a-zA-Z0-9_+.-]*)?(#([a-f0-9]+|latest|\*))?$`)
+
+func (r *Registry) validateReference(reference string) error {
+	if !conanReferenceRegexp.MatchString(reference) {
+		return fmt.Errorf("%s is an invalid Conan reference. It must be in the form of : name/version[@user/channel][#revision].", reference)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("Conan credentials require a token and a username in authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if len(credentials.Token) == 0 || len(credentials.AdditionalParameters["username"]) != 0 {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,215 @@
+package conan
+
+import (
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		name                string
+		sourceUrl           string
+		sourceToken         string
+		sourceUsername      string
+		destinationUrl      string
+		destinationToken    string
+		destinationUsername string
+		expectedScripts     []string
+	}{
+		{
+			name:                "public source",
+			sourceUrl:           "https://source.test/conan",
+			destinationUrl:      "https://destination.test/conan",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				"python -m pip install conan",
+				"conan remote remove conancenter",
+				`conan remote add pkgs_importer_source "https://source.test/conan"`,
+				`conan download "$PACKAGE_REFERENCE" --remote pkgs_importer_source`,
+				`conan remote add pkgs_importer_destination "https://destination.test/conan"`,
+				`conan remote login pkgs_importer_destination "username_for_destination" --password "TOKEN_FOR_DESTINATION"`,
+				`conan upload "$PACKAGE_REFERENCE" --remote pkgs_importer_destination --confirm`,
+			},
+		},
+		{
+			name:                "private source",
+			sourceUrl:           "https://source.test/conan",
+			sourceToken:         "TOKEN_FOR_SOURCE",
+			sourceUsername:      "username_for_source",
+			destinationUrl:      "https://destination.test/conan",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				"python -m pip install conan",
+				"conan remote remove conancenter",
+				`conan remote add pkgs_importer_source "https://source.test/conan"`,
+				`conan remote login pkgs_importer_source "username_for_source" --password "TOKEN_FOR_SOURCE"`,
+				`conan download "$PACKAGE_REFERENCE" --remote pkgs_importer_source`,
+				`conan remote add pkgs_importer_destination "https://destination.test/conan"`,
+				`conan remote login pkgs_importer_destination "username_for_destination" --password "TOKEN_FOR_DESTINATION"`,
+				`conan upload "$PACKAGE_REFERENCE" --remote pkgs_importer_destination --confirm`,
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: map[string]string{"username": spec.sourceUsername},
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: map[string]string{"username": spec.destinationUsername},
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+			require.Equal(t, spec.expectedScripts, scripts)
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"username": "user",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "conan",
+			},
+		},
+		{
+			name: "with registries with correct credentials and references",
+			pkgsImport: config.Import{
+				Type:        "conan",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"zlib":    {"1.2.13", "1.3@my_user/stable", "1.3@my_user/stable#*"},
+				"openssl": {"3.1.2#latest", "3.1.3#f3ec4d6c1b1bea2a3e7bb58e6e9c1b2d"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "conan",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "conan",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with user and no channel",
+			errorMessage: "zlib/1.2.13@my_user is an invalid Conan reference. It must be in the form of : name/version[@user/channel][#revision].",
+			pkgsImport: config.Import{
+				Type:        "conan",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"zlib": {"1.2.13@my_user"},
+			},
+		},
+		{
+			name:         "with upper case name",
+			errorMessage: "ZLib/1.2.13 is an invalid Conan reference.",
+			pkgsImport: config.Import{
+				Type:        "conan",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"ZLib": {"1.2.13"},
+			},
+		},
+		{
+			name:         "with invalid revision",
+			errorMessage: "zlib/1.2.13#first is an invalid Conan reference.",
+			pkgsImport: config.Import{
+				Type:        "conan",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"zlib": {"1.2.13#first"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{"PACKAGE_REFERENCE": "zlib/1.3@my_user/stable#*"}, new(Registry).AdditionalEnvVars("zlib", "1.3@my_user/stable#*"))
+}
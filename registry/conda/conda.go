@@ -0,0 +1,159 @@
+// Package conda is a set of simple functions that provide all the custom parts to handle
+// conda package imports from channel A to channel B.
+package conda
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a conda channel given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new conda registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for conda package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+const (
+	versionSeparator = ":"
+	allBuilds        = "*"
+)
+
+// AdditionalEnvVars returns the additional environment variables.
+// conda package versions are in the form of version[:build[:subdir]]. Without a build, all the
+// builds are imported. Without a subdir, the import script uses its default ones.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	parts := strings.SplitN(version, versionSeparator, 3)
+
+	build := allBuilds
+	if len(parts) > 1 && len(parts[1]) != 0 {
+		build = parts[1]
+	}
+
+	subdir := ""
+	if len(parts) > 2 {
+		subdir = parts[2]
+	}
+
+	return map[string]string{
+		"PACKAGE_BUILD":  build,
+		"PACKAGE_SUBDIR": subdir,
+	}
+}
+
+// importScript resolves the files in the source repodata.json, downloads them, verifies their
+// checksums and uploads them. See conda_import.py for the details.
+//
+//go:embed conda_import.py
+var importScript string
+
+const importScriptFile = "conda_import.py"
+
+// Scripts returns the script lines to execute a conda package import.
+// The files are read from <source url>/<subdir>/<file name> and uploaded with a PUT request to
+// <destination url>/<subdir>/<file name>. See
+// https://docs.conda.io/projects/conda/en/stable/user-guide/concepts/channels.html.
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 9)
+
+	scripts = append(scripts, fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript))
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Source.Credentials, "CONDA_SOURCE")...)
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Destination.Credentials, "CONDA_DESTINATION")...)
+	scripts = append(scripts, `package_version=$(echo $PACKAGE_VERSION | cut -d ":" -f 1)`)
+	scripts = append(scripts, fmt.Sprintf(`python3 %s "%s" "%s" "$PACKAGE_NAME" "$package_version" "$PACKAGE_BUILD" "$PACKAGE_SUBDIR"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL))
+
+	return scripts, nil
+}
+
+func (r *Registry) credentialsScripts(credentials config.Credentials, prefix string) []string {
+	return []string{
+		fmt.Sprintf(`export %s_TOKEN="%s"`, prefix, credentials.Token),
+		fmt.Sprintf(`export %s_USERNAME="%s"`, prefix, credentials.AdditionalParameters["username"]),
+		fmt.Sprintf(`export %s_HEADER_NAME="%s"`, prefix, credentials.AdditionalParameters["header_name"]),
+	}
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// The build is a glob pattern. The subdir is noarch or a platform like linux-64.
+// See https://docs.conda.io/projects/conda-build/en/stable/resources/package-spec.html
+var (
+	condaPackageNameRegexp = regexp.MustCompile(`^[a-z0-9_][a-z0-9_.-]*$`)
+	condaVersionRegexp     = regexp.MustCompile(`^[a-zA-Z0-9_.+!]+(:[a-zA-Z0-9_.*]*(:(noarch|[a-z0-9]+-[a-z0-9_]+))?)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !condaPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid conda package name. It must be lower case and only contain alphanumeric characters, _, . or -.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !condaVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid conda package version string. It must be in the form of : version[:build[:subdir]].", version)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("conda credentials require a token and a username or a token and a header_name for authenticated channels")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if credentials.Token == "" || credentials.AdditionalParameters["username"] != "" || credentials.AdditionalParameters["header_name"] != "" {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,87 @@
+"""Copies the files of a conda package version from a channel to another one.
+
+Usage: python3 conda_import.py <source channel url> <destination channel url> <name> <version> <build> [<subdir>]
+
+The build is a glob pattern matched against the build strings, * matches all of them.
+Without a subdir, linux-64 is searched and then noarch.
+Credentials are read from the CONDA_{SOURCE,DESTINATION}_{TOKEN,USERNAME,HEADER_NAME} environment variables.
+See https://docs.conda.io/projects/conda-build/en/stable/concepts/generating-index.html.
+"""
+
+import base64
+import fnmatch
+import gzip
+import hashlib
+import json
+import os
+import sys
+import urllib.error
+import urllib.parse
+import urllib.request
+
+DEFAULT_SUBDIRS = ("linux-64", "noarch")
+
+
+def auth_headers(prefix):
+    token = os.environ.get(prefix + "_TOKEN")
+    if not token:
+        return {}
+    username = os.environ.get(prefix + "_USERNAME")
+    if username:
+        return {"Authorization": "Basic " + base64.b64encode(("%s:%s" % (username, token)).encode()).decode()}
+    return {os.environ[prefix + "_HEADER_NAME"]: token}
+
+
+def open_url(url, prefix, data=None, method="GET"):
+    headers = {"User-Agent": "pkgs_importer", "Accept-Encoding": "gzip", "Content-Type": "application/octet-stream"}
+    headers.update(auth_headers(prefix))
+    return urllib.request.urlopen(urllib.request.Request(url, data=data, headers=headers, method=method))
+
+
+def read(url, prefix):
+    with open_url(url, prefix) as response:
+        if response.headers.get("Content-Encoding") == "gzip":
+            return gzip.decompress(response.read())
+        return response.read()
+
+
+def find_files(source, name, version, build, subdir):
+    for subdir in [subdir] if subdir else DEFAULT_SUBDIRS:
+        try:
+            repodata = json.loads(read("%s/%s/repodata.json" % (source, subdir), "CONDA_SOURCE"))
+        except urllib.error.HTTPError as error:
+            if error.code == 404:
+                continue
+            raise
+        files = []
+        for key in ("packages", "packages.conda"):
+            for file_name, record in repodata.get(key, {}).items():
+                if record["name"] == name and record["version"] == version and fnmatch.fnmatchcase(record["build"], build):
+                    files.append((subdir, file_name, record))
+        if files:
+            return files
+    sys.exit("version %s of package %s with build %s not found in %s" % (version, name, build, source))
+
+
+def verify(content, record, file_name):
+    if "sha256" in record:
+        valid = hashlib.sha256(content).hexdigest() == record["sha256"]
+    else:
+        valid = hashlib.md5(content).hexdigest() == record["md5"]
+    if not valid:
+        sys.exit("checksum mismatch for file %s" % file_name)
+
+
+def main(source, destination, name, version, build, subdir=""):
+    source, destination = source.rstrip("/"), destination.rstrip("/")
+    for subdir, file_name, record in find_files(source, name, version, build, subdir):
+        path = "%s/%s" % (subdir, urllib.parse.quote(file_name))
+        content = read("%s/%s" % (source, path), "CONDA_SOURCE")
+        verify(content, record, file_name)
+
+        with open_url("%s/%s" % (destination, path), "CONDA_DESTINATION", data=content, method="PUT") as response:
+            print("%s uploaded: %s" % (path, response.status))
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:7])
@@ -0,0 +1,195 @@
+package conda
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "https://conda.anaconda.org/conda-forge",
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/artifactory/conda-local",
+			Credentials: config.Credentials{
+				Token:                "TOKEN_FOR_DESTINATION",
+				AdditionalParameters: map[string]string{"username": "username_for_destination"},
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 9)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > conda_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `"%s/%s/repodata.json"`)
+	require.Equal(t, []string{
+		`export CONDA_SOURCE_TOKEN=""`,
+		`export CONDA_SOURCE_USERNAME=""`,
+		`export CONDA_SOURCE_HEADER_NAME=""`,
+		`export CONDA_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`,
+		`export CONDA_DESTINATION_USERNAME="username_for_destination"`,
+		`export CONDA_DESTINATION_HEADER_NAME=""`,
+		`package_version=$(echo $PACKAGE_VERSION | cut -d ":" -f 1)`,
+		`python3 conda_import.py "https://conda.anaconda.org/conda-forge" "https://destination.test/artifactory/conda-local" "$PACKAGE_NAME" "$package_version" "$PACKAGE_BUILD" "$PACKAGE_SUBDIR"`,
+	}, scripts[1:])
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"header_name": "X-JFrog-Art-Api",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "conda",
+			},
+		},
+		{
+			name: "with registries with correct credentials and packages",
+			pkgsImport: config.Import{
+				Type:        "conda",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"numpy":           {"1.26.0", "1.26.0:py311h64a7726_0", "1.26.0:py311*:osx-arm64"},
+				"python-dateutil": {"2.8.2:pyhd8ed1ab_0:noarch", "2.8.2::noarch"},
+				"openssl":         {"3.1.3"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "conda",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "conda",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with upper case name",
+			errorMessage: "NumPy is an invalid conda package name.",
+			pkgsImport: config.Import{
+				Type:        "conda",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"NumPy": {"1.26.0"},
+			},
+		},
+		{
+			name:         "with invalid subdir",
+			errorMessage: "1.26.0:py311_0:linux is an invalid conda package version string. It must be in the form of : version[:build[:subdir]].",
+			pkgsImport: config.Import{
+				Type:        "conda",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"numpy": {"1.26.0:py311_0:linux"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected map[string]string
+	}{
+		{
+			name:     "with version only",
+			version:  "1.26.0",
+			expected: map[string]string{"PACKAGE_BUILD": "*", "PACKAGE_SUBDIR": ""},
+		},
+		{
+			name:     "with build",
+			version:  "1.26.0:py311h64a7726_0",
+			expected: map[string]string{"PACKAGE_BUILD": "py311h64a7726_0", "PACKAGE_SUBDIR": ""},
+		},
+		{
+			name:     "with build and subdir",
+			version:  "1.26.0:py311*:osx-arm64",
+			expected: map[string]string{"PACKAGE_BUILD": "py311*", "PACKAGE_SUBDIR": "osx-arm64"},
+		},
+		{
+			name:     "with subdir only",
+			version:  "2.8.2::noarch",
+			expected: map[string]string{"PACKAGE_BUILD": "*", "PACKAGE_SUBDIR": "noarch"},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			require.Equal(t, spec.expected, new(Registry).AdditionalEnvVars("numpy", spec.version))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package debian is a set of simple functions that provide all the custom parts to handle
+// Debian package imports from an apt repository A to a registry B.
+package debian
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents an apt repository given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Debian registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Debian package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+const (
+	architectureSeparator = ":"
+	defaultArchitecture   = "amd64"
+)
+
+// architectureRegexp tells an architecture apart from the rest of a version with an epoch, like 1:2.3-1.
+// Debian upstream versions start with a digit while architectures start with a letter.
+var architectureRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// AdditionalEnvVars returns the additional environment variables.
+// Debian package versions are in the form of version[:architecture].
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	if index := strings.LastIndex(version, architectureSeparator); index != -1 {
+		if architecture := version[index+1:]; architectureRegexp.MatchString(architecture) {
+			return map[string]string{"PACKAGE_ARCHITECTURE": architecture}
+		}
+	}
+
+	return map[string]string{"PACKAGE_ARCHITECTURE": defaultArchitecture}
+}
+
+// importScript resolves the package in the source Packages index, downloads the .deb file,
+// verifies its checksum and uploads it. See debian_import.py for the details.
+//
+//go:embed debian_import.py
+var importScript string
+
+const importScriptFile = "debian_import.py"
+
+// Scripts returns the script lines to execute a Debian package import.
+// The apt repository format is described in https://wiki.debian.org/DebianRepository/Format.
+// The .deb file is uploaded with a PUT request to <destination url>/<file name>, like in the
+// KhulnaSoft Debian API: https://docs.khulnasoft.com/ee/api/packages/debian.html#upload-a-package-file.
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 8)
+
+	scripts = append(scripts, fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript))
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Source.Credentials, "DEBIAN_SOURCE")...)
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Destination.Credentials, "DEBIAN_DESTINATION")...)
+	scripts = append(scripts, fmt.Sprintf(`python3 %s "%s" "%s" "$PACKAGE_NAME" "$PACKAGE_VERSION" "$PACKAGE_ARCHITECTURE"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL))
+
+	return scripts, nil
+}
+
+func (r *Registry) credentialsScripts(credentials config.Credentials, prefix string) []string {
+	return []string{
+		fmt.Sprintf(`export %s_TOKEN="%s"`, prefix, credentials.Token),
+		fmt.Sprintf(`export %s_USERNAME="%s"`, prefix, credentials.AdditionalParameters["username"]),
+		fmt.Sprintf(`export %s_HEADER_NAME="%s"`, prefix, credentials.AdditionalParameters["header_name"]),
+	}
+}
+
+func (r *Registry) validate(importName string) error {
+	if !strings.Contains(r.pkgsImport.Source.URL, distributionsPath) {
+		return errInvalidSourceUrl
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html#source
+// and https://www.debian.org/doc/debian-policy/ch-controlfields.html#version
+var (
+	debianPackageNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]+$`)
+	debianVersionRegexp     = regexp.MustCompile(`^([0-9]+:)?[0-9][a-zA-Z0-9.+~-]*(:[a-z][a-z0-9-]*)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !debianPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid Debian package name. It must be lower case and only contain alphanumeric characters, +, . or -.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !debianVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid Debian package version string. It must be in the form of : [epoch:]version[:architecture].", version)
+	}
+	return nil
+}
+
+const distributionsPath = "/dists/"
+
+var (
+	errInvalidSourceUrl   = errors.New("Debian source url must point to a distribution component, like https://deb.debian.org/debian/dists/bookworm/main")
+	errInvalidCredentials = errors.New("Debian credentials require a token and a username or a token and a header_name for authenticated registries")
+)
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if credentials.Token == "" || credentials.AdditionalParameters["username"] != "" || credentials.AdditionalParameters["header_name"] != "" {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,113 @@
+"""Copies a Debian package from an apt repository to a destination upload endpoint.
+
+Usage: python3 debian_import.py <source url> <destination url> <name> <version[:architecture]> <architecture>
+
+The source url points to a component of a distribution, like https://deb.debian.org/debian/dists/bookworm/main.
+Credentials are read from the DEBIAN_{SOURCE,DESTINATION}_{TOKEN,USERNAME,HEADER_NAME} environment variables.
+See https://wiki.debian.org/DebianRepository/Format.
+"""
+
+import base64
+import bz2
+import gzip
+import hashlib
+import lzma
+import os
+import sys
+import urllib.error
+import urllib.parse
+import urllib.request
+
+INDEX_FILES = (("Packages.xz", lzma.open), ("Packages.gz", gzip.open), ("Packages.bz2", bz2.open), ("Packages", None))
+
+
+def auth_headers(prefix):
+    token = os.environ.get(prefix + "_TOKEN")
+    if not token:
+        return {}
+    username = os.environ.get(prefix + "_USERNAME")
+    if username:
+        return {"Authorization": "Basic " + base64.b64encode(("%s:%s" % (username, token)).encode()).decode()}
+    return {os.environ[prefix + "_HEADER_NAME"]: token}
+
+
+def open_url(url, prefix, data=None, method="GET"):
+    headers = {"User-Agent": "pkgs_importer", "Content-Type": "application/octet-stream"}
+    headers.update(auth_headers(prefix))
+    return urllib.request.urlopen(urllib.request.Request(url, data=data, headers=headers, method=method))
+
+
+def repository_root(source):
+    root, separator, _ = source.partition("/dists/")
+    if not separator:
+        sys.exit("%s is not a distribution component url" % source)
+    return root
+
+
+def stanzas(index):
+    stanza = {}
+    field = None
+    for line in index:
+        line = line.decode().rstrip("\n")
+        if not line.strip():
+            if stanza:
+                yield stanza
+            stanza, field = {}, None
+        elif line[0] in " \t":
+            if field:
+                stanza[field] += "\n" + line.strip()
+        else:
+            field, _, value = line.partition(":")
+            stanza[field] = value.strip()
+    if stanza:
+        yield stanza
+
+
+def find_package(source, name, version, architecture):
+    # Architecture independent packages are listed in the indexes of each architecture.
+    directories = ["binary-" + architecture]
+    if architecture == "all":
+        directories.append("binary-amd64")
+
+    for directory in directories:
+        for index_file, opener in INDEX_FILES:
+            try:
+                response = open_url("%s/%s/%s" % (source, directory, index_file), "DEBIAN_SOURCE")
+            except urllib.error.HTTPError as error:
+                if error.code == 404:
+                    continue
+                raise
+            with response:
+                index = opener(response) if opener else response
+                for stanza in stanzas(index):
+                    if stanza.get("Package") == name and stanza.get("Version") == version and stanza.get("Architecture") == architecture:
+                        return stanza
+            break
+    sys.exit("version %s of package %s for %s not found in %s" % (version, name, architecture, source))
+
+
+def with_file_name(destination, file_name):
+    url = urllib.parse.urlsplit(destination)
+    path = "%s/%s" % (url.path.rstrip("/"), urllib.parse.quote(file_name))
+    return urllib.parse.urlunsplit((url.scheme, url.netloc, path, url.query, url.fragment))
+
+
+def main(source, destination, name, version, architecture):
+    source = source.rstrip("/")
+    version = version.removesuffix(":" + architecture)
+    package = find_package(source, name, version, architecture)
+    if "SHA256" not in package:
+        sys.exit("package %s version %s has no SHA256 checksum in %s" % (name, version, source))
+
+    with open_url("%s/%s" % (repository_root(source), package["Filename"]), "DEBIAN_SOURCE") as response:
+        deb = response.read()
+    if hashlib.sha256(deb).hexdigest() != package["SHA256"]:
+        sys.exit("checksum mismatch for package %s version %s" % (name, version))
+
+    file_name = os.path.basename(package["Filename"])
+    with open_url(with_file_name(destination, file_name), "DEBIAN_DESTINATION", data=deb, method="PUT") as response:
+        print(response.read().decode())
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:6])
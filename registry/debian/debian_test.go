@@ -0,0 +1,210 @@
+package debian
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "https://source.test/debian/dists/bookworm/main",
+			Credentials: config.Credentials{
+				Token:                "TOKEN_FOR_SOURCE",
+				AdditionalParameters: map[string]string{"username": "username_for_source"},
+			},
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/api/v4/projects/1/packages/debian?distribution=bookworm&component=main",
+			Credentials: config.Credentials{
+				Token:                "TOKEN_FOR_DESTINATION",
+				AdditionalParameters: map[string]string{"header_name": "PRIVATE-TOKEN"},
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 8)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > debian_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `"binary-" + architecture`)
+	require.Equal(t, []string{
+		`export DEBIAN_SOURCE_TOKEN="TOKEN_FOR_SOURCE"`,
+		`export DEBIAN_SOURCE_USERNAME="username_for_source"`,
+		`export DEBIAN_SOURCE_HEADER_NAME=""`,
+		`export DEBIAN_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`,
+		`export DEBIAN_DESTINATION_USERNAME=""`,
+		`export DEBIAN_DESTINATION_HEADER_NAME="PRIVATE-TOKEN"`,
+		`python3 debian_import.py "https://source.test/debian/dists/bookworm/main" "https://destination.test/api/v4/projects/1/packages/debian?distribution=bookworm&component=main" "$PACKAGE_NAME" "$PACKAGE_VERSION" "$PACKAGE_ARCHITECTURE"`,
+	}, scripts[1:])
+}
+
+func TestNewRegistry(t *testing.T) {
+	validSource := config.Registry{URL: "https://deb.debian.org/debian/dists/bookworm/main"}
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"header_name": "PRIVATE-TOKEN",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type:   "debian",
+				Source: validSource,
+			},
+		},
+		{
+			name: "with registries with correct credentials and packages",
+			pkgsImport: config.Import{
+				Type:        "debian",
+				Source:      validSource,
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"hello":      {"2.10-3", "2.10-3:arm64", "1:2.10-3+deb12u1:all"},
+				"libstdc++6": {"12.2.0-14"},
+				"python3.11": {"3.11.2-6~bpo12+1:i386"},
+			},
+		},
+		{
+			name:         "with source url without distribution",
+			errorMessage: errInvalidSourceUrl.Error(),
+			pkgsImport: config.Import{
+				Type:        "debian",
+				Source:      config.Registry{URL: "https://source.test/debian"},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "debian",
+				Source: config.Registry{
+					URL: validSource.URL,
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "debian",
+				Source: validSource,
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with upper case name",
+			errorMessage: "Hello is an invalid Debian package name.",
+			pkgsImport: config.Import{
+				Type:        "debian",
+				Source:      validSource,
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"Hello": {"2.10-3"},
+			},
+		},
+		{
+			name:         "with version not starting with a digit",
+			errorMessage: "v2.10-3 is an invalid Debian package version string. It must be in the form of : [epoch:]version[:architecture].",
+			pkgsImport: config.Import{
+				Type:        "debian",
+				Source:      validSource,
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"hello": {"v2.10-3"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	tests := []struct {
+		name                 string
+		version              string
+		expectedArchitecture string
+	}{
+		{
+			name:                 "without architecture",
+			version:              "2.10-3",
+			expectedArchitecture: "amd64",
+		},
+		{
+			name:                 "with architecture",
+			version:              "2.10-3:arm64",
+			expectedArchitecture: "arm64",
+		},
+		{
+			name:                 "with epoch",
+			version:              "1:2.10-3",
+			expectedArchitecture: "amd64",
+		},
+		{
+			name:                 "with epoch and architecture",
+			version:              "1:2.10-3:all",
+			expectedArchitecture: "all",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			expected := map[string]string{"PACKAGE_ARCHITECTURE": spec.expectedArchitecture}
+			require.Equal(t, expected, new(Registry).AdditionalEnvVars("hello", spec.version))
+		})
+	}
+}
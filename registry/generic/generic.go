@@ -0,0 +1,138 @@
+// Package generic is a set of simple functions that provide all the custom parts to handle
+// generic package file imports from registry A to registry B.
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a generic packages registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new generic packages registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for generic package imports.
+func (r *Registry) ImageName() string {
+	return "curlimages/curl:latest"
+}
+
+const fileSeparator = ":"
+
+// AdditionalEnvVars returns the additional environment variables.
+// Generic package versions are in the form of version:file.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	_, file, _ := strings.Cut(version, fileSeparator)
+
+	return map[string]string{"PACKAGE_FILE": file}
+}
+
+// Scripts returns the script lines to execute a generic package file import.
+// The file is downloaded and uploaded with curl. See
+// https://docs.khulnasoft.com/ee/user/packages/generic_packages/.
+func (r *Registry) Scripts() ([]string, error) {
+	source := r.pkgsImport.Source
+	destination := r.pkgsImport.Destination
+
+	return []string{
+		`package_version=$(echo $PACKAGE_VERSION | cut -d ":" -f 1)`,
+		fmt.Sprintf(`curl --fail --silent --show-error --location%s --output "$PACKAGE_FILE" "%s"`, r.authOption(source.Credentials), r.fileUrl(source)),
+		fmt.Sprintf(`curl --fail --silent --show-error%s --upload-file "$PACKAGE_FILE" "%s"`, r.authOption(destination.Credentials), r.fileUrl(destination)),
+	}, nil
+}
+
+func (r *Registry) fileUrl(registry config.Registry) string {
+	return fmt.Sprintf("%s/$PACKAGE_NAME/$package_version/$PACKAGE_FILE", strings.TrimSuffix(registry.URL, "/"))
+}
+
+func (r *Registry) authOption(credentials config.Credentials) string {
+	if len(credentials.Token) == 0 {
+		return ""
+	}
+
+	if username := credentials.AdditionalParameters["username"]; len(username) != 0 {
+		return fmt.Sprintf(` --user "%s:%s"`, username, credentials.Token)
+	}
+
+	return fmt.Sprintf(` --header "%s: %s"`, credentials.AdditionalParameters["header_name"], credentials.Token)
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://docs.khulnasoft.com/ee/user/packages/generic_packages/#publish-a-package-file
+var (
+	genericPackageNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._+-]+$`)
+	genericVersionRegexp     = regexp.MustCompile(`^[a-zA-Z0-9._+-]+:[a-zA-Z0-9._+~-]+$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !genericPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid generic package name. It can only contain alphanumeric characters, ., _, + or -.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !genericVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid generic package version string. It must be in the form of : version:file.", version)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("generic packages credentials require a token and a username or a token and a header_name for authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if credentials.Token == "" || credentials.AdditionalParameters["username"] != "" || credentials.AdditionalParameters["header_name"] != "" {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,227 @@
+package generic
+
+import (
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		name                        string
+		sourceUrl                   string
+		sourceToken                 string
+		sourceAdditionalParams      map[string]string
+		destinationUrl              string
+		destinationToken            string
+		destinationAdditionalParams map[string]string
+		expectedDownload            string
+		expectedUpload              string
+	}{
+		{
+			name:                        "public source, destination with header and token",
+			sourceUrl:                   "https://source.test/packages/generic/",
+			destinationUrl:              "https://destination.test/packages/generic",
+			destinationToken:            "TOKEN_FOR_DESTINATION",
+			destinationAdditionalParams: map[string]string{"header_name": "PRIVATE-TOKEN"},
+			expectedDownload:            `curl --fail --silent --show-error --location --output "$PACKAGE_FILE" "https://source.test/packages/generic/$PACKAGE_NAME/$package_version/$PACKAGE_FILE"`,
+			expectedUpload:              `curl --fail --silent --show-error --header "PRIVATE-TOKEN: TOKEN_FOR_DESTINATION" --upload-file "$PACKAGE_FILE" "https://destination.test/packages/generic/$PACKAGE_NAME/$package_version/$PACKAGE_FILE"`,
+		},
+		{
+			name:                        "source with username and token, destination with username and token",
+			sourceUrl:                   "https://source.test/packages/generic",
+			sourceToken:                 "TOKEN_FOR_SOURCE",
+			sourceAdditionalParams:      map[string]string{"username": "USER_FOR_SOURCE"},
+			destinationUrl:              "https://destination.test/packages/generic",
+			destinationToken:            "TOKEN_FOR_DESTINATION",
+			destinationAdditionalParams: map[string]string{"username": "USER_FOR_DESTINATION"},
+			expectedDownload:            `curl --fail --silent --show-error --location --user "USER_FOR_SOURCE:TOKEN_FOR_SOURCE" --output "$PACKAGE_FILE" "https://source.test/packages/generic/$PACKAGE_NAME/$package_version/$PACKAGE_FILE"`,
+			expectedUpload:              `curl --fail --silent --show-error --user "USER_FOR_DESTINATION:TOKEN_FOR_DESTINATION" --upload-file "$PACKAGE_FILE" "https://destination.test/packages/generic/$PACKAGE_NAME/$package_version/$PACKAGE_FILE"`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: spec.sourceAdditionalParams,
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: spec.destinationAdditionalParams,
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+
+			require.Equal(t, []string{
+				`package_version=$(echo $PACKAGE_VERSION | cut -d ":" -f 1)`,
+				spec.expectedDownload,
+				spec.expectedUpload,
+			}, scripts)
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"header_name": "PRIVATE-TOKEN",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "generic",
+			},
+		},
+		{
+			name: "with registries with correct credentials and packages",
+			pkgsImport: config.Import{
+				Type:        "generic",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my_binary": {"1.2.3:my_binary-linux-amd64", "1.2.3:my_binary-darwin-arm64"},
+				"my.tool":   {"0.4.0:tool.tar.gz"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "generic",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "generic",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with invalid package name",
+			errorMessage: "my/binary is an invalid generic package name. It can only contain alphanumeric characters, ., _, + or -.",
+			pkgsImport: config.Import{
+				Type:        "generic",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my/binary": {"1.2.3:file"},
+			},
+		},
+		{
+			name:         "with version without file",
+			errorMessage: "1.2.3 is an invalid generic package version string. It must be in the form of : version:file.",
+			pkgsImport: config.Import{
+				Type:        "generic",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my_binary": {"1.2.3"},
+			},
+		},
+		{
+			name:         "with file in a sub directory",
+			errorMessage: "1.2.3:bin/file is an invalid generic package version string. It must be in the form of : version:file.",
+			pkgsImport: config.Import{
+				Type:        "generic",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my_binary": {"1.2.3:bin/file"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "curlimages/curl:latest", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected map[string]string
+	}{
+		{
+			name:     "with a file",
+			version:  "1.2.3:my_binary-linux-amd64",
+			expected: map[string]string{"PACKAGE_FILE": "my_binary-linux-amd64"},
+		},
+		{
+			name:     "without a file",
+			version:  "1.2.3",
+			expected: map[string]string{"PACKAGE_FILE": ""},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			require.Equal(t, spec.expected, new(Registry).AdditionalEnvVars("name", spec.version))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+)
+
+// Transfer copies a generic package file without curl: the file is downloaded from the source
+// and uploaded with a PUT request to the destination, like in the scripts.
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	destination := r.pkgsImport.Destination
+	version, file, _ := strings.Cut(version, fileSeparator)
+
+	content, err := client.Download(ctx, r.transferFileUrl(source, name, version, file), transfer.CredentialsAuth(source.Credentials))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return client.Upload(ctx, http.MethodPut, r.transferFileUrl(destination, name, version, file), transfer.CredentialsAuth(destination.Credentials), content.Body(), content.Size())
+}
+
+func (r *Registry) transferFileUrl(registry config.Registry, name, version, file string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", strings.TrimSuffix(registry.URL, "/"), name, version, file)
+}
+
+// Exists sends a HEAD request for the file in the destination.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	destination := r.pkgsImport.Destination
+	version, file, _ := strings.Cut(version, fileSeparator)
+
+	return client.Exists(ctx, r.transferFileUrl(destination, name, version, file), transfer.CredentialsAuth(destination.Credentials))
+}
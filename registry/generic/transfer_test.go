@@ -0,0 +1,87 @@
+package generic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		expectedError string
+	}{
+		{
+			name:    "existing file",
+			version: "1.2.3:file.txt",
+		},
+		{
+			name:          "missing file",
+			version:       "1.2.3:missing.txt",
+			expectedError: "GET <source>/package/1.2.3/missing.txt returned 404 Not Found",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "SOURCE_TOKEN", r.Header.Get("Private-Token"))
+
+				if r.URL.Path != "/package/1.2.3/file.txt" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte("content"))
+			}))
+			t.Cleanup(source.Close)
+
+			uploaded := map[string]string{}
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPut, r.Method)
+				username, password, _ := r.BasicAuth()
+				require.Equal(t, "user:DESTINATION_TOKEN", username+":"+password)
+				body, _ := io.ReadAll(r.Body)
+				uploaded[r.URL.Path] = string(body)
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{
+						URL: source.URL,
+						Credentials: config.Credentials{
+							Token:                "SOURCE_TOKEN",
+							AdditionalParameters: map[string]string{"header_name": "Private-Token"},
+						},
+					},
+					Destination: config.Registry{
+						URL: destination.URL + "/",
+						Credentials: config.Credentials{
+							Token:                "DESTINATION_TOKEN",
+							AdditionalParameters: map[string]string{"username": "user"},
+						},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), "package", spec.version)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, strings.Replace(spec.expectedError, "<source>", source.URL, 1))
+				require.Empty(t, uploaded)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"/package/1.2.3/file.txt": "content"}, uploaded)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package golang is a set of simple functions that provide all the custom parts to handle
+// Go module imports from a GOPROXY registry A to a GOPROXY registry B.
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Go modules proxy given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Go modules registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Go module imports.
+func (r *Registry) ImageName() string {
+	return "curlimages/curl:latest"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The GOPROXY protocol requires module paths and versions to be case encoded, see
+// https://go.dev/ref/mod#goproxy-protocol.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{
+		"PACKAGE_ESCAPED_NAME":    escape(name),
+		"PACKAGE_ESCAPED_VERSION": escape(version),
+	}
+}
+
+// The files served by a GOPROXY for a given module version, see https://go.dev/ref/mod#goproxy-protocol.
+// The .info file is uploaded last so that the destination never advertises a version without its content.
+var (
+	downloadedExtensions = []string{"info", "mod", "zip"}
+	uploadedExtensions   = []string{"mod", "zip", "info"}
+)
+
+// Scripts returns the script lines to execute a Go module import.
+// Files are downloaded from the source proxy and uploaded to the destination proxy with curl.
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 8)
+
+	scripts = append(scripts, "mkdir _pkg")
+	for _, ext := range downloadedExtensions {
+		scripts = append(scripts, r.downloadScript(ext))
+	}
+	scripts = append(scripts, "cd _pkg")
+	for _, ext := range uploadedExtensions {
+		scripts = append(scripts, r.uploadScript(ext))
+	}
+
+	return scripts, nil
+}
+
+func (r *Registry) downloadScript(ext string) string {
+	source := r.pkgsImport.Source
+
+	return fmt.Sprintf(`curl --fail --silent --show-error --location%s --output "_pkg/$PACKAGE_ESCAPED_VERSION.%s" "%s"`, r.authOption(source.Credentials), ext, r.fileUrl(source, ext))
+}
+
+func (r *Registry) uploadScript(ext string) string {
+	destination := r.pkgsImport.Destination
+
+	return fmt.Sprintf(`curl --fail --silent --show-error%s --upload-file "$PACKAGE_ESCAPED_VERSION.%s" "%s"`, r.authOption(destination.Credentials), ext, r.fileUrl(destination, ext))
+}
+
+func (r *Registry) fileUrl(registry config.Registry, ext string) string {
+	return fmt.Sprintf("%s/$PACKAGE_ESCAPED_NAME/@v/$PACKAGE_ESCAPED_VERSION.%s", strings.TrimSuffix(registry.URL, "/"), ext)
+}
+
+func (r *Registry) authOption(credentials config.Credentials) string {
+	if len(credentials.Token) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(` --user "%s:%s"`, credentials.AdditionalParameters["username"], credentials.Token)
+}
+
+// escape applies the case encoding of the GOPROXY protocol: every upper case letter
+// is replaced by an exclamation mark followed by the lower case letter.
+func escape(s string) string {
+	escaped := new(strings.Builder)
+
+	for _, c := range s {
+		if unicode.IsUpper(c) {
+			escaped.WriteRune('!')
+			escaped.WriteRune(unicode.ToLower(c))
+		} else {
+			escaped.WriteRune(c)
+		}
+	}
+
+	return escaped.String()
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var modulePathRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)+(/[a-zA-Z0-9._~+-]+)*$`)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !modulePathRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid Go module path. It must start with a domain name like example.com/my/module.", name)
+	}
+	return nil
+}
+
+var moduleVersionRegexp = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?(\+incompatible)?$`)
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !moduleVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid Go module version. It must be a canonical semantic version like v1.2.3.", version)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("Go modules credentials require a token and a username in authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if len(credentials.Token) == 0 || len(credentials.AdditionalParameters["username"]) != 0 {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
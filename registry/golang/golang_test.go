@@ -0,0 +1,251 @@
+package golang
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ScriptsTest struct {
+	name                string
+	sourceUrl           string
+	sourceToken         string
+	sourceUsername      string
+	destinationUrl      string
+	destinationToken    string
+	destinationUsername string
+}
+
+func TestScripts(t *testing.T) {
+	tests := []ScriptsTest{
+		{
+			name:                "public source",
+			sourceUrl:           "http://source.test",
+			destinationUrl:      "https://destination.test",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+		},
+		{
+			name:                "private source",
+			sourceUrl:           "http://source.test/",
+			sourceToken:         "TOKEN_FOR_SOURCE",
+			sourceUsername:      "username_for_source",
+			destinationUrl:      "https://destination.test/",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: map[string]string{"username": spec.sourceUsername},
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: map[string]string{"username": spec.destinationUsername},
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+
+			assertSourceAccess(t, scripts, spec)
+			assertDestinationAccess(t, scripts, spec)
+		})
+	}
+}
+
+func assertSourceAccess(t *testing.T, scripts []string, spec ScriptsTest) {
+	auth := ""
+	if len(spec.sourceToken) != 0 {
+		auth = fmt.Sprintf(` --user "%s:%s"`, spec.sourceUsername, spec.sourceToken)
+	}
+
+	for _, ext := range []string{"info", "mod", "zip"} {
+		expected := fmt.Sprintf(`curl --fail --silent --show-error --location%s --output "_pkg/$PACKAGE_ESCAPED_VERSION.%s" "http://source.test/$PACKAGE_ESCAPED_NAME/@v/$PACKAGE_ESCAPED_VERSION.%s"`, auth, ext, ext)
+		require.Contains(t, scripts, expected)
+	}
+}
+
+func assertDestinationAccess(t *testing.T, scripts []string, spec ScriptsTest) {
+	for _, ext := range []string{"info", "mod", "zip"} {
+		expected := fmt.Sprintf(`curl --fail --silent --show-error --user "%s:%s" --upload-file "$PACKAGE_ESCAPED_VERSION.%s" "https://destination.test/$PACKAGE_ESCAPED_NAME/@v/$PACKAGE_ESCAPED_VERSION.%s"`, spec.destinationUsername, spec.destinationToken, ext, ext)
+		require.Contains(t, scripts, expected)
+	}
+
+	require.Equal(t, `curl --fail --silent --show-error --user "username_for_destination:TOKEN_FOR_DESTINATION" --upload-file "$PACKAGE_ESCAPED_VERSION.info" "https://destination.test/$PACKAGE_ESCAPED_NAME/@v/$PACKAGE_ESCAPED_VERSION.info"`, scripts[len(scripts)-1])
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"username": "user",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "golang",
+			},
+		},
+		{
+			name: "with registries with correct credentials",
+			pkgsImport: config.Import{
+				Type:        "golang",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"example.com/my/module":    {"v1.2.3", "v1.3.0-rc.1"},
+				"github.com/Org/Module/v2": {"v2.0.0+incompatible", "v2.0.1-0.20230101000000-abcdefabcdef"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "golang",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "golang",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with module path without domain",
+			errorMessage: "my/module is an invalid Go module path. It must start with a domain name like example.com/my/module.",
+			pkgsImport: config.Import{
+				Type:        "golang",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"my/module": {"v1.2.3"},
+			},
+		},
+		{
+			name:         "with version without v prefix",
+			errorMessage: "1.2.3 is an invalid Go module version. It must be a canonical semantic version like v1.2.3.",
+			pkgsImport: config.Import{
+				Type:        "golang",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"example.com/my/module": {"1.2.3"},
+			},
+		},
+		{
+			name:         "with non canonical version",
+			errorMessage: "v1.2 is an invalid Go module version. It must be a canonical semantic version like v1.2.3.",
+			pkgsImport: config.Import{
+				Type:        "golang",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"example.com/my/module": {"v1.2"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "curlimages/curl:latest", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkgName  string
+		version  string
+		expected map[string]string
+	}{
+		{
+			name:    "with lower case module",
+			pkgName: "example.com/my/module",
+			version: "v1.2.3",
+			expected: map[string]string{
+				"PACKAGE_ESCAPED_NAME":    "example.com/my/module",
+				"PACKAGE_ESCAPED_VERSION": "v1.2.3",
+			},
+		},
+		{
+			name:    "with upper case module and version",
+			pkgName: "github.com/Azure/azure-sdk-for-go",
+			version: "v1.2.3-RC",
+			expected: map[string]string{
+				"PACKAGE_ESCAPED_NAME":    "github.com/!azure/azure-sdk-for-go",
+				"PACKAGE_ESCAPED_VERSION": "v1.2.3-!r!c",
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			require.Equal(t, spec.expected, new(Registry).AdditionalEnvVars(spec.pkgName, spec.version))
+		})
+	}
+}
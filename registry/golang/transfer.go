@@ -0,0 +1,52 @@
+package golang
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+)
+
+// Transfer copies a Go module version without curl. Like in the scripts, the .info, .mod and
+// .zip files are downloaded first and the .info file is uploaded last.
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	destination := r.pkgsImport.Destination
+
+	files := make(map[string]*transfer.File, len(downloadedExtensions))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	for _, ext := range downloadedExtensions {
+		file, err := client.Download(ctx, r.transferFileUrl(source, name, version, ext), transfer.CredentialsAuth(source.Credentials))
+		if err != nil {
+			return err
+		}
+		files[ext] = file
+	}
+
+	for _, ext := range uploadedExtensions {
+		if err := client.Upload(ctx, http.MethodPut, r.transferFileUrl(destination, name, version, ext), transfer.CredentialsAuth(destination.Credentials), files[ext].Body(), files[ext].Size()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) transferFileUrl(registry config.Registry, name, version, ext string) string {
+	return fmt.Sprintf("%s/%s/@v/%s.%s", strings.TrimSuffix(registry.URL, "/"), escape(name), escape(version), ext)
+}
+
+// Exists sends a HEAD request for the .info file in the destination. It's the last uploaded file.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	destination := r.pkgsImport.Destination
+
+	return client.Exists(ctx, r.transferFileUrl(destination, name, version, "info"), transfer.CredentialsAuth(destination.Credentials))
+}
@@ -0,0 +1,84 @@
+package golang
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		expectedError string
+	}{
+		{
+			name:    "existing version",
+			version: "v1.2.3",
+		},
+		{
+			name:          "missing version",
+			version:       "v4.5.6",
+			expectedError: "GET <source>/example.com/!my/module/@v/v4.5.6.info returned 404 Not Found",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				prefix := "/example.com/!my/module/@v/v1.2.3."
+				if !strings.HasPrefix(r.URL.Path, prefix) {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(strings.TrimPrefix(r.URL.Path, prefix) + " content"))
+			}))
+			t.Cleanup(source.Close)
+
+			uploads := []string{}
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPut, r.Method)
+				username, password, _ := r.BasicAuth()
+				require.Equal(t, "user:DESTINATION_TOKEN", username+":"+password)
+				body, _ := io.ReadAll(r.Body)
+				uploads = append(uploads, r.URL.Path+" "+string(body))
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{URL: source.URL},
+					Destination: config.Registry{
+						URL: destination.URL,
+						Credentials: config.Credentials{
+							Token:                "DESTINATION_TOKEN",
+							AdditionalParameters: map[string]string{"username": "user"},
+						},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), "example.com/My/module", spec.version)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, strings.Replace(spec.expectedError, "<source>", source.URL, 1))
+				require.Empty(t, uploads)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, []string{
+				"/example.com/!my/module/@v/v1.2.3.mod mod content",
+				"/example.com/!my/module/@v/v1.2.3.zip zip content",
+				"/example.com/!my/module/@v/v1.2.3.info info content",
+			}, uploads)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package helm is a set of simple functions that provide all the custom parts to handle
+// Helm chart imports from registry A to registry B.
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Helm chart registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Helm registry given an import.
+// Some validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Helm chart imports.
+func (r *Registry) ImageName() string {
+	return "alpine/helm:latest"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The Helm scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+const ociScheme = "oci"
+
+// Scripts returns the script lines to execute a Helm chart import.
+// Registries are either classic chart repositories, serving an index.yaml file, or OCI registries.
+// The kind of registry is selected by the URL scheme. See:
+// - https://helm.sh/docs/helm/helm_pull/
+// - https://helm.sh/docs/helm/helm_push/
+// - https://chartmuseum.com/docs/#uploading-a-chart-package
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 8)
+
+	pullScripts, err := r.pullScripts()
+	if err != nil {
+		return nil, err
+	}
+	scripts = append(scripts, pullScripts...)
+	scripts = append(scripts, "cd _pkg")
+
+	pushScripts, err := r.pushScripts()
+	if err != nil {
+		return nil, err
+	}
+	scripts = append(scripts, pushScripts...)
+
+	return scripts, nil
+}
+
+func (r *Registry) pullScripts() ([]string, error) {
+	source := r.pkgsImport.Source
+
+	address, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.Scheme != ociScheme {
+		return []string{
+			fmt.Sprintf(`helm pull "$PACKAGE_NAME" --version "$PACKAGE_VERSION" --repo %s%s --destination _pkg`, source.URL, r.credentialsOptions(source.Credentials)),
+		}, nil
+	}
+
+	scripts := make([]string, 0, 3)
+	if len(source.Credentials.Token) != 0 {
+		scripts = append(scripts, r.loginScript(address.Host, source.Credentials))
+	}
+	scripts = append(scripts, fmt.Sprintf(`helm pull "%s/$PACKAGE_NAME" --version "$PACKAGE_VERSION" --destination _pkg`, strings.TrimSuffix(source.URL, "/")))
+	if len(source.Credentials.Token) != 0 {
+		scripts = append(scripts, fmt.Sprintf("helm registry logout %s", address.Host))
+	}
+
+	return scripts, nil
+}
+
+func (r *Registry) pushScripts() ([]string, error) {
+	destination := r.pkgsImport.Destination
+
+	address, err := url.Parse(destination.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.Scheme != ociScheme {
+		return []string{
+			fmt.Sprintf(`curl --fail --silent --show-error --user "%s:%s" --form "chart=@$(ls *.tgz | head -n 1)" "%s"`, destination.Credentials.AdditionalParameters["username"], destination.Credentials.Token, destination.URL),
+		}, nil
+	}
+
+	return []string{
+		r.loginScript(address.Host, destination.Credentials),
+		fmt.Sprintf("helm push $(ls *.tgz | head -n 1) %s", strings.TrimSuffix(destination.URL, "/")),
+	}, nil
+}
+
+func (r *Registry) credentialsOptions(credentials config.Credentials) string {
+	if len(credentials.Token) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(` --username "%s" --password "%s"`, credentials.AdditionalParameters["username"], credentials.Token)
+}
+
+func (r *Registry) loginScript(host string, credentials config.Credentials) string {
+	return fmt.Sprintf(`echo "%s" | helm registry login %s --username "%s" --password-stdin`, credentials.Token, host, credentials.AdditionalParameters["username"])
+}
+
+func (r *Registry) validate() error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	return r.validateCredentials(r.pkgsImport.Destination.Credentials)
+}
+
+var errInvalidCredentials = errors.New("Helm credentials require a token and a username in authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if len(credentials.Token) == 0 || len(credentials.AdditionalParameters["username"]) != 0 {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,195 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		name                string
+		sourceUrl           string
+		sourceToken         string
+		sourceUsername      string
+		destinationUrl      string
+		destinationToken    string
+		destinationUsername string
+		expectedScripts     []string
+	}{
+		{
+			name:                "public chart repository to chart repository",
+			sourceUrl:           "https://source.test/charts",
+			destinationUrl:      "https://destination.test/api/charts",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`helm pull "$PACKAGE_NAME" --version "$PACKAGE_VERSION" --repo https://source.test/charts --destination _pkg`,
+				"cd _pkg",
+				`curl --fail --silent --show-error --user "username_for_destination:TOKEN_FOR_DESTINATION" --form "chart=@$(ls *.tgz | head -n 1)" "https://destination.test/api/charts"`,
+			},
+		},
+		{
+			name:                "private chart repository to OCI registry",
+			sourceUrl:           "https://source.test/charts",
+			sourceToken:         "TOKEN_FOR_SOURCE",
+			sourceUsername:      "username_for_source",
+			destinationUrl:      "oci://destination.test/charts/",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`helm pull "$PACKAGE_NAME" --version "$PACKAGE_VERSION" --repo https://source.test/charts --username "username_for_source" --password "TOKEN_FOR_SOURCE" --destination _pkg`,
+				"cd _pkg",
+				`echo "TOKEN_FOR_DESTINATION" | helm registry login destination.test --username "username_for_destination" --password-stdin`,
+				"helm push $(ls *.tgz | head -n 1) oci://destination.test/charts",
+			},
+		},
+		{
+			name:                "private OCI registry to chart repository",
+			sourceUrl:           "oci://source.test/charts",
+			sourceToken:         "TOKEN_FOR_SOURCE",
+			sourceUsername:      "username_for_source",
+			destinationUrl:      "https://destination.test/api/charts",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`echo "TOKEN_FOR_SOURCE" | helm registry login source.test --username "username_for_source" --password-stdin`,
+				`helm pull "oci://source.test/charts/$PACKAGE_NAME" --version "$PACKAGE_VERSION" --destination _pkg`,
+				"helm registry logout source.test",
+				"cd _pkg",
+				`curl --fail --silent --show-error --user "username_for_destination:TOKEN_FOR_DESTINATION" --form "chart=@$(ls *.tgz | head -n 1)" "https://destination.test/api/charts"`,
+			},
+		},
+		{
+			name:                "public OCI registry to OCI registry",
+			sourceUrl:           "oci://source.test/charts",
+			destinationUrl:      "oci://destination.test/charts",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`helm pull "oci://source.test/charts/$PACKAGE_NAME" --version "$PACKAGE_VERSION" --destination _pkg`,
+				"cd _pkg",
+				`echo "TOKEN_FOR_DESTINATION" | helm registry login destination.test --username "username_for_destination" --password-stdin`,
+				"helm push $(ls *.tgz | head -n 1) oci://destination.test/charts",
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: map[string]string{"username": spec.sourceUsername},
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: map[string]string{"username": spec.destinationUsername},
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+			require.Equal(t, spec.expectedScripts, scripts)
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "helm",
+			},
+		},
+		{
+			name: "with registries with correct credentials",
+			pkgsImport: config.Import{
+				Type: "helm",
+				Source: config.Registry{
+					URL: "http://source.registry",
+				},
+				Destination: config.Registry{
+					URL: "oci://destination.registry",
+					Credentials: config.Credentials{
+						AdditionalParameters: map[string]string{
+							"username": "user",
+						},
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "helm",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+				},
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "helm",
+				Source: config.Registry{
+					URL: "http://source.registry",
+				},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			registry, err := NewRegistry(spec.pkgsImport)
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "alpine/helm:latest", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{}, new(Registry).AdditionalEnvVars("name", "version"))
+}
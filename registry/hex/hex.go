@@ -0,0 +1,141 @@
+// Package hex is a set of simple functions that provide all the custom parts to handle
+// Hex (Elixir and Erlang) package imports from repository A to repository B.
+package hex
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Hex repository given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Hex registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Hex package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The Hex scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+// importScript verifies the signed registry resource of the package, downloads the tarball,
+// verifies its checksum and publishes it. See hex_import.py for the details.
+//
+//go:embed hex_import.py
+var importScript string
+
+const importScriptFile = "hex_import.py"
+
+// hexpmUrl is the hex.pm repository, whose resources are signed with hexpmPublicKey. See
+// https://hex.pm/docs/public_keys
+const (
+	hexpmUrl       = "https://repo.hex.pm"
+	hexpmPublicKey = `-----BEGIN PUBLIC KEY-----
+MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEApqREcFDt5vV21JVe2QNB
+Edvzk6w36aNFhVGWN5toNJRjRJ6m4hIuG4KaXtDWVLjnvct6MYMfqhC79HAGwyF+
+IqR6Q6a5bbFSsImgBJwz1oadoVKD6ZNetAuCIK84cjMrEFRkELtEIPNHblCzUkkM
+3rS9+DPlnfG8hBvGi6tvQIuZmXGCxF/73hU0/MyGhbmEjIKRtG6b0sJYKelRLTPW
+XgK7s5pESgiwf2YC/2MGDXjAJfpfCd0RpLdvd4eRiXtVlE9qO9bND94E7PgQ/xqZ
+J1i2xWFndWa6nfFnRxZmCStCOZWYYPlaxr+FZceFbpMwzTNs4g3d4tLNUcbKAIH4
+0wIDAQAB
+-----END PUBLIC KEY-----`
+)
+
+// Scripts returns the script lines to execute a Hex package import.
+// The source is a repository and the destination is a Hex API. See:
+// - https://github.com/hexpm/specifications/blob/main/endpoints.md#repository
+// - https://github.com/hexpm/specifications/blob/main/registry-v2.md
+func (r *Registry) Scripts() ([]string, error) {
+	return []string{
+		fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript),
+		fmt.Sprintf("export HEX_PUBLIC_KEY=\"%s\"", r.publicKey()),
+		fmt.Sprintf(`export HEX_SOURCE_TOKEN="%s"`, r.pkgsImport.Source.Credentials.Token),
+		fmt.Sprintf(`export HEX_DESTINATION_TOKEN="%s"`, r.pkgsImport.Destination.Credentials.Token),
+		fmt.Sprintf(`python3 %s "%s" "%s" "$PACKAGE_NAME" "$PACKAGE_VERSION"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL),
+	}, nil
+}
+
+// publicKey returns the public key of the source repository: the one of the import, or the hex.pm
+// one by default.
+func (r *Registry) publicKey() string {
+	if len(r.pkgsImport.PublicKey) != 0 {
+		return strings.TrimSpace(r.pkgsImport.PublicKey)
+	}
+
+	return hexpmPublicKey
+}
+
+func (r *Registry) validate(importName string) error {
+	if len(r.pkgsImport.PublicKey) == 0 && strings.TrimSuffix(r.pkgsImport.Source.URL, "/") != hexpmUrl {
+		return fmt.Errorf("import %q needs the public_key of its source repository %q", importName, r.pkgsImport.Source.URL)
+	}
+
+	if len(r.pkgsImport.PublicKey) != 0 && !strings.HasPrefix(strings.TrimSpace(r.pkgsImport.PublicKey), "-----BEGIN PUBLIC KEY-----") {
+		return fmt.Errorf("the public_key of import %q must be a PEM encoded public key", importName)
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://github.com/hexpm/specifications/blob/main/package_metadata.md
+var (
+	hexPackageNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	hexVersionRegexp     = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !hexPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid Hex package name. It must start with a lower case letter and only contain lower case alphanumeric characters or _.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !hexVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid Hex package version. It must be a semantic version like 1.2.3.", version)
+	}
+	return nil
+}
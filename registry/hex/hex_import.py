@@ -0,0 +1,138 @@
+"""Copies a package release from a Hex repository to a Hex compatible API.
+
+Usage: python3 hex_import.py <source repository url> <destination api url> <name> <version>
+
+Keys are read from the HEX_SOURCE_TOKEN and HEX_DESTINATION_TOKEN environment variables. The PEM
+encoded public key of the source repository is read from the HEX_PUBLIC_KEY environment variable.
+See https://github.com/hexpm/specifications/blob/main/registry-v2.md.
+"""
+
+import base64
+import gzip
+import hashlib
+import io
+import os
+import re
+import sys
+import tarfile
+import urllib.request
+
+# DER encoding of the SHA-512 algorithm identifier, see RFC 8017 section 9.2.
+SHA512_PREFIX = bytes.fromhex("3051300d060960864801650304020305000440")
+
+
+def request(url, token, data=None, method="GET"):
+    headers = {"User-Agent": "pkgs_importer"}
+    if token:
+        headers["Authorization"] = token
+    if data is not None:
+        headers["Content-Type"] = "application/octet-stream"
+    req = urllib.request.Request(url, data=data, headers=headers, method=method)
+    with urllib.request.urlopen(req) as response:
+        return response.read()
+
+
+def varint(data, offset):
+    result, shift = 0, 0
+    while True:
+        byte = data[offset]
+        offset += 1
+        result |= (byte & 0x7F) << shift
+        if not byte & 0x80:
+            return result, offset
+        shift += 7
+
+
+def protobuf_fields(data):
+    """Yields the (field number, value) pairs of a protobuf message. Only varints and bytes are expected."""
+    offset = 0
+    while offset < len(data):
+        key, offset = varint(data, offset)
+        number, wire_type = key >> 3, key & 0x7
+        if wire_type == 0:
+            value, offset = varint(data, offset)
+        elif wire_type == 2:
+            length, offset = varint(data, offset)
+            value, offset = data[offset : offset + length], offset + length
+        else:
+            sys.exit("unexpected protobuf wire type %d" % wire_type)
+        yield number, value
+
+
+def der_element(data, offset):
+    tag = data[offset]
+    length = data[offset + 1]
+    offset += 2
+    if length & 0x80:
+        size = length & 0x7F
+        length = int.from_bytes(data[offset : offset + size], "big")
+        offset += size
+    return tag, data[offset : offset + length], offset + length
+
+
+def public_key(pem):
+    """Returns the modulus and the exponent of a PEM encoded RSA SubjectPublicKeyInfo."""
+    der = base64.b64decode(re.sub(r"-----[A-Z ]+-----|\s", "", pem))
+    _, spki, _ = der_element(der, 0)
+    _, _, offset = der_element(spki, 0)
+    _, bit_string, _ = der_element(spki, offset)
+    _, rsa_key, _ = der_element(bit_string[1:], 0)
+    _, modulus, offset = der_element(rsa_key, 0)
+    _, exponent, _ = der_element(rsa_key, offset)
+    return int.from_bytes(modulus, "big"), int.from_bytes(exponent, "big")
+
+
+def verify_signature(payload, signature, pem):
+    modulus, exponent = public_key(pem)
+    size = (modulus.bit_length() + 7) // 8
+    digest_info = SHA512_PREFIX + hashlib.sha512(payload).digest()
+    expected = b"\x00\x01" + b"\xff" * (size - len(digest_info) - 3) + b"\x00" + digest_info
+    if pow(int.from_bytes(signature, "big"), exponent, modulus).to_bytes(size, "big") != expected:
+        sys.exit("invalid signature of the registry resource")
+
+
+def find_release(source, token, name, version, pem):
+    signed = dict(protobuf_fields(gzip.decompress(request("%s/packages/%s" % (source, name), token))))
+    verify_signature(signed[1], signed[2], pem)
+
+    package_name = None
+    releases = []
+    for number, value in protobuf_fields(signed[1]):
+        if number == 1:
+            releases.append(dict(protobuf_fields(value)))
+        elif number == 2:
+            package_name = value.decode()
+    if package_name != name:
+        sys.exit("the registry resource is for package %s instead of %s" % (package_name, name))
+
+    for release in releases:
+        if release[1].decode() == version:
+            return release
+    sys.exit("version %s of package %s not found in %s" % (version, name, source))
+
+
+def verify_tarball(tarball, release):
+    if 5 in release:
+        valid = hashlib.sha256(tarball).digest() == release[5]
+    else:
+        with tarfile.open(fileobj=io.BytesIO(tarball)) as archive:
+            inner = b"".join(archive.extractfile(member).read() for member in ("VERSION", "metadata.config", "contents.tar.gz"))
+        valid = hashlib.sha256(inner).digest() == release[2]
+    if not valid:
+        sys.exit("checksum mismatch for the tarball")
+
+
+def main(source, destination, name, version):
+    source_token = os.environ.get("HEX_SOURCE_TOKEN")
+    destination_token = os.environ.get("HEX_DESTINATION_TOKEN")
+    source, destination = source.rstrip("/"), destination.rstrip("/")
+
+    release = find_release(source, source_token, name, version, os.environ["HEX_PUBLIC_KEY"])
+    tarball = request("%s/tarballs/%s-%s.tar" % (source, name, version), source_token)
+    verify_tarball(tarball, release)
+
+    print(request(destination + "/publish", destination_token, data=tarball, method="POST").decode())
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:5])
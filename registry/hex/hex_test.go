@@ -0,0 +1,143 @@
+package hex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "https://repo.hex.pm",
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/api",
+			Credentials: config.Credentials{
+				Token: "TOKEN_FOR_DESTINATION",
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 5)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > hex_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `verify_signature(signed[1], signed[2], pem)`)
+	require.Equal(t, "export HEX_PUBLIC_KEY=\""+hexpmPublicKey+"\"", scripts[1])
+	require.Equal(t, `export HEX_SOURCE_TOKEN=""`, scripts[2])
+	require.Equal(t, `export HEX_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`, scripts[3])
+	require.Equal(t, `python3 hex_import.py "https://repo.hex.pm" "https://destination.test/api" "$PACKAGE_NAME" "$PACKAGE_VERSION"`, scripts[4])
+}
+
+func TestScriptsWithPublicKey(t *testing.T) {
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source:      config.Registry{URL: "https://hex.acme.test/repos/acme"},
+			Destination: config.Registry{URL: "https://destination.test/api"},
+			PublicKey:   "-----BEGIN PUBLIC KEY-----\nACME\n-----END PUBLIC KEY-----\n",
+		},
+	}
+
+	scripts, err := registry.Scripts()
+	require.Nil(t, err)
+
+	require.Equal(t, "export HEX_PUBLIC_KEY=\"-----BEGIN PUBLIC KEY-----\nACME\n-----END PUBLIC KEY-----\"", scripts[1])
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with no packages",
+		},
+		{
+			name:       "with another source and its public key",
+			pkgsImport: config.Import{Source: config.Registry{URL: "https://hex.acme.test/repos/acme"}, PublicKey: "-----BEGIN PUBLIC KEY-----\nACME\n-----END PUBLIC KEY-----"},
+		},
+		{
+			name:         "with another source without public key",
+			errorMessage: `import "import1" needs the public_key of its source repository "https://hex.acme.test/repos/acme"`,
+			pkgsImport:   config.Import{Source: config.Registry{URL: "https://hex.acme.test/repos/acme"}},
+		},
+		{
+			name:         "with a public key that is not PEM encoded",
+			errorMessage: `the public_key of import "import1" must be a PEM encoded public key`,
+			pkgsImport:   config.Import{Source: config.Registry{URL: "https://repo.hex.pm/"}, PublicKey: "ACME"},
+		},
+		{
+			name: "with valid packages",
+			pkgs: map[string][]string{
+				"jason":        {"1.4.1"},
+				"phoenix_html": {"4.0.0-rc.0", "3.3.3"},
+				"plug":         {"1.15.2+build.1"},
+			},
+		},
+		{
+			name:         "with package name with a dash",
+			errorMessage: "phoenix-html is an invalid Hex package name. It must start with a lower case letter and only contain lower case alphanumeric characters or _.",
+			pkgs: map[string][]string{
+				"phoenix-html": {"3.3.3"},
+			},
+		},
+		{
+			name:         "with package name starting with _",
+			errorMessage: "_plug is an invalid Hex package name.",
+			pkgs: map[string][]string{
+				"_plug": {"1.0.0"},
+			},
+		},
+		{
+			name:         "with invalid version",
+			errorMessage: "1.4 is an invalid Hex package version. It must be a semantic version like 1.2.3.",
+			pkgs: map[string][]string{
+				"jason": {"1.4"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			pkgsImport := spec.pkgsImport
+			pkgsImport.Type = "hex"
+			if len(pkgsImport.Source.URL) == 0 {
+				pkgsImport.Source.URL = hexpmUrl
+			}
+
+			registry, err := NewRegistry(pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{}, new(Registry).AdditionalEnvVars("name", "version"))
+}
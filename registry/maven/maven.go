@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/version"
 	"golang.org/x/exp/slices"
 )
 
@@ -201,21 +202,27 @@ func (r *Registry) validatePackageName(name string) error {
 
 var validPackagings = []string{"pom", "jar", "maven-plugin", "ejb", "war", "ear", "rar", "aar"}
 
-func (r *Registry) validatePackageVersion(version string) error {
-	count := strings.Count(version, mavenCoordinatesSeparator)
+// validatePackageVersion checks the packaging of a version, like 1.2.3:war. The version specs, like
+// latest:5, are resolved to versions before the import and have no packaging.
+func (r *Registry) validatePackageVersion(packageVersion string) error {
+	if version.IsSpec(packageVersion) {
+		return nil
+	}
+
+	count := strings.Count(packageVersion, mavenCoordinatesSeparator)
 	if count == 0 {
 		return nil
 	}
 
 	if count == 1 {
-		packaging := strings.Split(version, mavenCoordinatesSeparator)[1]
+		packaging := strings.Split(packageVersion, mavenCoordinatesSeparator)[1]
 		if slices.Contains(validPackagings, packaging) {
 			return nil
 		}
 		return fmt.Errorf("%s is an invalid Maven packaging string. It must be one of : %s.", packaging, validPackagings)
 	}
 
-	return fmt.Errorf("%s is an invalid Maven version string. It must be in the form of : version[:packaging].", version)
+	return fmt.Errorf("%s is an invalid Maven version string. It must be in the form of : version[:packaging].", packageVersion)
 }
 
 var errInvalidCredentials = errors.New("Maven credentials require a token and a username or a token and a header_name for authenticated registries")
@@ -316,6 +316,28 @@ func TestNewRegistry(t *testing.T) {
 				"my.company:package2": {"1.2.3:war"},
 			},
 		},
+		{
+			name: "with packages with version specs",
+			pkgsImport: config.Import{
+				Type: "maven",
+				Source: config.Registry{
+					URL: "http://source.registry",
+				},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						AdditionalParameters: map[string]string{
+							"username": "user",
+						},
+						Token: "1234567890",
+					},
+				},
+			},
+			pkgs: map[string][]string{
+				"my.company:package1": {"latest:5"},
+				"my.company:package2": {"latest", ">=1.0 <2.0"},
+			},
+		},
 		{
 			name:         "with packages with invalid packaging",
 			errorMessage: "zip is an invalid Maven packaging string. It must be one of : [pom jar maven-plugin ejb war ear rar aar].",
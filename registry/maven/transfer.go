@@ -0,0 +1,552 @@
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"golang.org/x/exp/slices"
+)
+
+// The checksums uploaded next to each file, like mvn deploy:deploy-file does.
+var uploadedChecksums = []string{"sha1", "md5"}
+
+// maxListingDepth bounds the number of directory levels walked to find the artifacts of a groupId
+// prefix.
+const maxListingDepth = 8
+
+var linkRegexp = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*"([^"]*)"`)
+
+// now returns the lastUpdated value of the metadata. Tests can override it.
+var now = time.Now
+
+// Transfer copies a Maven package version without mvn. The pom file and the artifact file are
+// downloaded, verified against the source .sha1 files when they exist and uploaded with their
+// checksums. The destination maven-metadata.xml file is then updated with the new version. See
+// https://maven.apache.org/repositories/layout.html.
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	destination := r.pkgsImport.Destination
+	groupId, artifactId, _ := strings.Cut(name, mavenCoordinatesSeparator)
+	artifactPath, version, filePaths := r.filePaths(name, version)
+
+	for _, filePath := range filePaths {
+		if err := r.copyFile(ctx, client, source, destination, filePath); err != nil {
+			return err
+		}
+	}
+
+	return r.updateMetadata(ctx, client, destination, groupId, artifactId, artifactPath, version)
+}
+
+// TransferLock returns the destination maven-metadata.xml url of the artifact. The transfers of its
+// versions read, update and upload the file: they can't run at the same time.
+func (r *Registry) TransferLock(name, version string) string {
+	artifactPath, _, _ := r.filePaths(name, version)
+
+	return r.fileUrl(r.pkgsImport.Destination, artifactPath+"/maven-metadata.xml")
+}
+
+// Exists sends a HEAD request for the artifact file in the destination. It's uploaded after the pom file.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	destination := r.pkgsImport.Destination
+	_, _, filePaths := r.filePaths(name, version)
+
+	return client.Exists(ctx, r.fileUrl(destination, filePaths[len(filePaths)-1]), transfer.CredentialsAuth(destination.Credentials))
+}
+
+// filePaths returns the artifact path, the version without the packaging and the paths of the files
+// of a package version: the pom file and, unless the packaging is pom, the artifact file.
+func (r *Registry) filePaths(name, version string) (string, string, []string) {
+	groupId, artifactId, _ := strings.Cut(name, mavenCoordinatesSeparator)
+	version, packaging, _ := strings.Cut(version, mavenCoordinatesSeparator)
+	if len(packaging) == 0 {
+		packaging = "jar"
+	}
+
+	artifactPath := fmt.Sprintf("%s/%s", strings.ReplaceAll(groupId, ".", "/"), artifactId)
+	versionPath := fmt.Sprintf("%s/%s", artifactPath, version)
+	filePaths := []string{fmt.Sprintf("%s/%s-%s.pom", versionPath, artifactId, version)}
+	if packaging != "pom" {
+		filePaths = append(filePaths, fmt.Sprintf("%s/%s-%s.%s", versionPath, artifactId, version, packaging))
+	}
+
+	return artifactPath, version, filePaths
+}
+
+func (r *Registry) copyFile(ctx context.Context, client *transfer.Client, source, destination config.Registry, filePath string) error {
+	file, err := r.download(ctx, client, source, filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return r.upload(ctx, client, destination, filePath, file)
+}
+
+func (r *Registry) download(ctx context.Context, client *transfer.Client, registry config.Registry, filePath string) (*transfer.File, error) {
+	auth := transfer.CredentialsAuth(registry.Credentials)
+
+	file, err := client.Download(ctx, r.fileUrl(registry, filePath), auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.verify(ctx, client, registry, filePath, file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// verify checks the file against the .sha1 file of the registry, when it exists.
+func (r *Registry) verify(ctx context.Context, client *transfer.Client, registry config.Registry, filePath string, file *transfer.File) error {
+	checksum, err := client.Get(ctx, r.fileUrl(registry, filePath+".sha1"), transfer.CredentialsAuth(registry.Credentials))
+	if transfer.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Some repositories append the file name to the checksum.
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return file.VerifyChecksum("sha1", fields[0], filePath)
+}
+
+func (r *Registry) upload(ctx context.Context, client *transfer.Client, registry config.Registry, filePath string, file *transfer.File) error {
+	auth := transfer.CredentialsAuth(registry.Credentials)
+
+	if err := client.Upload(ctx, http.MethodPut, r.fileUrl(registry, filePath), auth, file.Body(), file.Size()); err != nil {
+		return err
+	}
+
+	for _, algorithm := range uploadedChecksums {
+		checksum, err := file.Checksum(algorithm)
+		if err != nil {
+			return err
+		}
+
+		if _, err := client.Do(ctx, http.MethodPut, r.fileUrl(registry, filePath+"."+algorithm), auth, []byte(checksum)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// metadata is the maven-metadata.xml file of an artifact, see
+// https://maven.apache.org/ref/3.9.4/maven-repository-metadata/repository-metadata.html.
+type metadata struct {
+	XMLName    xml.Name `xml:"metadata"`
+	GroupId    string   `xml:"groupId"`
+	ArtifactId string   `xml:"artifactId"`
+	Versioning struct {
+		Latest      string   `xml:"latest,omitempty"`
+		Release     string   `xml:"release,omitempty"`
+		Versions    []string `xml:"versions>version"`
+		LastUpdated string   `xml:"lastUpdated,omitempty"`
+	} `xml:"versioning"`
+}
+
+// Versions lists the versions of the source maven-metadata.xml file.
+func (r *Registry) Versions(ctx context.Context, client *transfer.Client, name string) ([]string, error) {
+	groupId, artifactId, _ := strings.Cut(name, mavenCoordinatesSeparator)
+	artifactPath, _, _ := r.filePaths(name, "")
+
+	current, err := r.metadata(ctx, client, r.pkgsImport.Source, groupId, artifactId, artifactPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return current.Versioning.Versions, nil
+}
+
+// metadata downloads the maven-metadata.xml file of an artifact. A missing file is returned empty.
+func (r *Registry) metadata(ctx context.Context, client *transfer.Client, registry config.Registry, groupId, artifactId, artifactPath string) (metadata, error) {
+	metadataPath := artifactPath + "/maven-metadata.xml"
+	current := metadata{GroupId: groupId, ArtifactId: artifactId}
+
+	content, err := client.Get(ctx, r.fileUrl(registry, metadataPath), transfer.CredentialsAuth(registry.Credentials))
+	if transfer.IsNotFound(err) {
+		return current, nil
+	}
+	if err != nil {
+		return current, err
+	}
+
+	if err := xml.Unmarshal(content, &current); err != nil {
+		return current, fmt.Errorf("invalid metadata file %s: %w", metadataPath, err)
+	}
+
+	return current, nil
+}
+
+func (r *Registry) updateMetadata(ctx context.Context, client *transfer.Client, registry config.Registry, groupId, artifactId, artifactPath, packageVersion string) error {
+	metadataPath := artifactPath + "/maven-metadata.xml"
+
+	current, err := r.metadata(ctx, client, registry, groupId, artifactId, artifactPath)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(current.Versioning.Versions, packageVersion) {
+		current.Versioning.Versions = append(current.Versioning.Versions, packageVersion)
+	}
+	// An older version can be imported after a newer one: latest and release are the most recent
+	// versions of the list with the Maven ordering, release ignoring the SNAPSHOT versions.
+	current.Versioning.Latest, current.Versioning.Release = "", ""
+	for _, v := range current.Versioning.Versions {
+		if len(current.Versioning.Latest) == 0 || version.CompareMaven(v, current.Versioning.Latest) > 0 {
+			current.Versioning.Latest = v
+		}
+		if !version.IsMavenSnapshot(v) && (len(current.Versioning.Release) == 0 || version.CompareMaven(v, current.Versioning.Release) > 0) {
+			current.Versioning.Release = v
+		}
+	}
+	current.Versioning.LastUpdated = now().UTC().Format("20060102150405")
+
+	content, err := xml.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return r.upload(ctx, client, registry, metadataPath, transfer.NewFile(append([]byte(xml.Header), content...)))
+}
+
+func (r *Registry) fileUrl(registry config.Registry, filePath string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(registry.URL, "/"), filePath)
+}
+
+// Packages walks the directory listings of the source from the groupId directory of the prefix. A
+// directory with a maven-metadata.xml file is an artifact, its parent directories are its groupId.
+func (r *Registry) Packages(ctx context.Context, client *transfer.Client, prefix string) ([]string, error) {
+	groupId, _, complete := strings.Cut(prefix, mavenCoordinatesSeparator)
+	if !complete {
+		// The last segment of the groupId prefix can be incomplete, like acme in com.acme*.
+		index := strings.LastIndex(groupId, ".")
+		groupId = groupId[:index+1]
+	}
+
+	dirPath := strings.ReplaceAll(strings.TrimSuffix(groupId, "."), ".", "/")
+	if len(dirPath) != 0 {
+		dirPath += "/"
+	}
+
+	names := []string{}
+	err := r.walk(ctx, client, dirPath, maxListingDepth, &names)
+
+	return names, err
+}
+
+func (r *Registry) walk(ctx context.Context, client *transfer.Client, dirPath string, depth int, names *[]string) error {
+	source := r.pkgsImport.Source
+
+	entries, err := r.listDirectory(ctx, client, source, dirPath)
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(entries, "maven-metadata.xml") {
+		artifactPath := strings.TrimSuffix(dirPath, "/")
+		if index := strings.LastIndex(artifactPath, "/"); index != -1 {
+			*names = append(*names, strings.ReplaceAll(artifactPath[:index], "/", ".")+mavenCoordinatesSeparator+artifactPath[index+1:])
+		}
+		return nil
+	}
+
+	if depth == 0 {
+		return nil
+	}
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			if err := r.walk(ctx, client, dirPath+entry, depth-1, names); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// listDirectory returns the files and the sub directories, with a trailing slash, linked by the
+// directory listing page. The links to other pages are ignored.
+func (r *Registry) listDirectory(ctx context.Context, client *transfer.Client, registry config.Registry, dirPath string) ([]string, error) {
+	dirUrl := r.fileUrl(registry, dirPath)
+
+	listing, err := client.Get(ctx, dirUrl, transfer.CredentialsAuth(registry.Credentials), "Accept", "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(dirUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []string{}
+	for _, match := range linkRegexp.FindAllStringSubmatch(string(listing), -1) {
+		link, err := base.Parse(html.UnescapeString(match[1]))
+		if err != nil || link.Host != base.Host || !strings.HasPrefix(link.Path, base.Path) {
+			continue
+		}
+
+		entry := strings.TrimPrefix(link.Path, base.Path)
+		if len(entry) != 0 && !strings.Contains(strings.TrimSuffix(entry, "/"), "/") {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// maxPomDepth bounds the number of parent and imported POM files read for a package version.
+const maxPomDepth = 10
+
+// pom is the part of a POM file needed to find the dependencies of an artifact, see
+// https://maven.apache.org/pom.html.
+type pom struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupId    string `xml:"groupId"`
+		ArtifactId string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	DependencyManagement struct {
+		Dependencies []pomDependency `xml:"dependencies>dependency"`
+	} `xml:"dependencyManagement"`
+	Dependencies []pomDependency `xml:"dependencies>dependency"`
+}
+
+type pomDependency struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+}
+
+func (d pomDependency) key() string {
+	return d.GroupId + mavenCoordinatesSeparator + d.ArtifactId
+}
+
+// The dependency scopes needed at runtime, see
+// https://maven.apache.org/guides/introduction/introduction-to-dependency-mechanism.html#dependency-scope.
+var runtimeScopes = []string{"", "compile", "runtime"}
+
+var propertyRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// Dependencies reads the compile and runtime dependencies of the source POM file and of its parent
+// POM files. The versions come from the dependencies, or from the dependency management sections of
+// these POM files and of the imported BOM files, with their properties. The parent POM files and the
+// imported BOM files are dependencies too, with the pom packaging. Optional dependencies are not
+// included.
+func (r *Registry) Dependencies(ctx context.Context, client *transfer.Client, name, packageVersion string) ([]version.Dependency, error) {
+	chain, err := r.pomChain(ctx, client, name, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := pomProperties(chain)
+	dependencies := []version.Dependency{}
+	add := func(dependency version.Dependency) {
+		if !slices.Contains(dependencies, dependency) {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	for _, p := range chain[1:] {
+		add(version.Dependency{Name: p.GroupId + mavenCoordinatesSeparator + p.ArtifactId, Version: p.Version + mavenCoordinatesSeparator + "pom"})
+	}
+
+	managed, imports, err := r.managedVersions(ctx, client, chain, properties, maxPomDepth)
+	if err != nil {
+		return nil, err
+	}
+	for _, bom := range imports {
+		add(bom)
+	}
+
+	for _, p := range chain {
+		for _, d := range p.Dependencies {
+			if !slices.Contains(runtimeScopes, d.Scope) || d.Optional == "true" {
+				continue
+			}
+
+			d.GroupId = interpolate(d.GroupId, properties)
+			d.Version = interpolate(d.Version, properties)
+			if len(d.Version) == 0 {
+				d.Version = managed[d.key()]
+			}
+			if len(d.Version) == 0 || strings.Contains(d.Version, "${") {
+				return nil, fmt.Errorf("no version for the dependency %s of %s %s", d.key(), name, packageVersion)
+			}
+
+			add(pomDependencyVersion(d))
+		}
+	}
+
+	return dependencies, nil
+}
+
+// pomDependencyVersion returns the dependency with its version, or the constraint of a version
+// range. The pom type is kept as a packaging.
+func pomDependencyVersion(d pomDependency) version.Dependency {
+	dependency := version.Dependency{Name: d.key()}
+
+	if constraint, ok := version.FromInterval(d.Version); ok {
+		dependency.Constraint = constraint
+		return dependency
+	}
+
+	dependency.Version = d.Version
+	if d.Type == "pom" {
+		dependency.Version += mavenCoordinatesSeparator + "pom"
+	}
+
+	return dependency
+}
+
+// pomChain downloads the POM file of the package version and its parent POM files, the closest
+// first.
+func (r *Registry) pomChain(ctx context.Context, client *transfer.Client, name, packageVersion string) ([]pom, error) {
+	source := r.pkgsImport.Source
+	chain := []pom{}
+
+	for len(chain) < maxPomDepth {
+		_, _, filePaths := r.filePaths(name, packageVersion)
+		content, err := client.Get(ctx, r.fileUrl(source, filePaths[0]), transfer.CredentialsAuth(source.Credentials))
+		if err != nil {
+			return nil, err
+		}
+
+		var current pom
+		if err := xml.Unmarshal(content, &current); err != nil {
+			return nil, fmt.Errorf("invalid POM file %s: %w", filePaths[0], err)
+		}
+		// The groupId and the version are inherited from the parent when they are missing.
+		if len(current.GroupId) == 0 {
+			current.GroupId = current.Parent.GroupId
+		}
+		if len(current.Version) == 0 {
+			current.Version = current.Parent.Version
+		}
+		chain = append(chain, current)
+
+		if len(current.Parent.ArtifactId) == 0 {
+			return chain, nil
+		}
+		name = current.Parent.GroupId + mavenCoordinatesSeparator + current.Parent.ArtifactId
+		packageVersion = current.Parent.Version + mavenCoordinatesSeparator + "pom"
+	}
+
+	return nil, fmt.Errorf("more than %d parent POM files for %s", maxPomDepth, name)
+}
+
+// pomProperties returns the properties of the POM files, the closest ones overriding the others,
+// with the project properties.
+func pomProperties(chain []pom) map[string]string {
+	properties := map[string]string{}
+
+	for index := len(chain) - 1; index >= 0; index-- {
+		for _, entry := range chain[index].Properties.Entries {
+			properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+		}
+	}
+
+	project := chain[0]
+	for _, prefix := range []string{"project.", "pom.", ""} {
+		properties[prefix+"groupId"] = project.GroupId
+		properties[prefix+"artifactId"] = project.ArtifactId
+		properties[prefix+"version"] = project.Version
+	}
+	properties["project.parent.groupId"] = project.Parent.GroupId
+	properties["project.parent.version"] = project.Parent.Version
+
+	return properties
+}
+
+// managedVersions returns the versions of the dependency management sections of the POM files,
+// the closest ones overriding the others, and the BOM files they import. The versions of the
+// imported BOM files are used when the POM files don't have one.
+func (r *Registry) managedVersions(ctx context.Context, client *transfer.Client, chain []pom, properties map[string]string, depth int) (map[string]string, []version.Dependency, error) {
+	managed := map[string]string{}
+	imports := []version.Dependency{}
+
+	for index := len(chain) - 1; index >= 0; index-- {
+		for _, d := range chain[index].DependencyManagement.Dependencies {
+			d.GroupId = interpolate(d.GroupId, properties)
+			d.Version = interpolate(d.Version, properties)
+
+			if d.Scope == "import" && d.Type == "pom" {
+				imports = append(imports, version.Dependency{Name: d.key(), Version: d.Version + mavenCoordinatesSeparator + "pom"})
+				continue
+			}
+			managed[d.key()] = d.Version
+		}
+	}
+
+	if depth == 0 {
+		return managed, imports, nil
+	}
+
+	for _, bom := range imports {
+		bomChain, err := r.pomChain(ctx, client, bom.Name, bom.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bomManaged, bomImports, err := r.managedVersions(ctx, client, bomChain, pomProperties(bomChain), depth-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for key, v := range bomManaged {
+			if _, ok := managed[key]; !ok {
+				managed[key] = v
+			}
+		}
+		imports = append(imports, bomImports...)
+	}
+
+	return managed, imports, nil
+}
+
+// interpolate replaces the ${name} references with the values of the properties. The unknown
+// properties are kept.
+func interpolate(value string, properties map[string]string) string {
+	for i := 0; i < maxPomDepth && strings.Contains(value, "${"); i++ {
+		value = propertyRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+			if v, ok := properties[reference[2:len(reference)-1]]; ok {
+				return v
+			}
+			return reference
+		})
+	}
+
+	return strings.TrimSpace(value)
+}
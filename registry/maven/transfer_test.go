@@ -0,0 +1,389 @@
+package maven
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/stretchr/testify/require"
+)
+
+const artifactPath = "/com/example/artifact"
+
+func TestTransfer(t *testing.T) {
+	now = func() time.Time { return time.Date(2023, 9, 1, 12, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name             string
+		version          string
+		sourceFiles      map[string]string
+		existingMetadata string
+		expectedUploads  []string
+		expectedMetadata string
+		expectedError    string
+	}{
+		{
+			name:    "jar without existing metadata",
+			version: "1.2.3",
+			sourceFiles: map[string]string{
+				"/1.2.3/artifact-1.2.3.pom":      "pom",
+				"/1.2.3/artifact-1.2.3.pom.sha1": "acb4a94f3c944150fb89f07d87b019e224c73a27  artifact-1.2.3.pom",
+				"/1.2.3/artifact-1.2.3.jar":      "jar",
+			},
+			expectedUploads: []string{
+				"/1.2.3/artifact-1.2.3.pom",
+				"/1.2.3/artifact-1.2.3.pom.sha1",
+				"/1.2.3/artifact-1.2.3.pom.md5",
+				"/1.2.3/artifact-1.2.3.jar",
+				"/1.2.3/artifact-1.2.3.jar.sha1",
+				"/1.2.3/artifact-1.2.3.jar.md5",
+				"/maven-metadata.xml",
+				"/maven-metadata.xml.sha1",
+				"/maven-metadata.xml.md5",
+			},
+			expectedMetadata: `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>artifact</artifactId>
+  <versioning>
+    <latest>1.2.3</latest>
+    <release>1.2.3</release>
+    <versions>
+      <version>1.2.3</version>
+    </versions>
+    <lastUpdated>20230901123000</lastUpdated>
+  </versioning>
+</metadata>`,
+		},
+		{
+			name:    "pom with existing metadata",
+			version: "2.0.0-SNAPSHOT:pom",
+			sourceFiles: map[string]string{
+				"/2.0.0-SNAPSHOT/artifact-2.0.0-SNAPSHOT.pom": "pom",
+			},
+			existingMetadata: `<metadata><groupId>com.example</groupId><artifactId>artifact</artifactId><versioning><latest>1.2.3</latest><release>1.2.3</release><versions><version>1.2.3</version></versions></versioning></metadata>`,
+			expectedUploads: []string{
+				"/2.0.0-SNAPSHOT/artifact-2.0.0-SNAPSHOT.pom",
+				"/2.0.0-SNAPSHOT/artifact-2.0.0-SNAPSHOT.pom.sha1",
+				"/2.0.0-SNAPSHOT/artifact-2.0.0-SNAPSHOT.pom.md5",
+				"/maven-metadata.xml",
+				"/maven-metadata.xml.sha1",
+				"/maven-metadata.xml.md5",
+			},
+			expectedMetadata: `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>artifact</artifactId>
+  <versioning>
+    <latest>2.0.0-SNAPSHOT</latest>
+    <release>1.2.3</release>
+    <versions>
+      <version>1.2.3</version>
+      <version>2.0.0-SNAPSHOT</version>
+    </versions>
+    <lastUpdated>20230901123000</lastUpdated>
+  </versioning>
+</metadata>`,
+		},
+		{
+			name:    "older version with existing metadata",
+			version: "31.1-jre:pom",
+			sourceFiles: map[string]string{
+				"/31.1-jre/artifact-31.1-jre.pom": "pom",
+			},
+			existingMetadata: `<metadata><groupId>com.example</groupId><artifactId>artifact</artifactId><versioning><latest>33.0-SNAPSHOT</latest><release>31.1-jre</release><versions><version>32.0.1-jre</version><version>33.0-SNAPSHOT</version></versions></versioning></metadata>`,
+			expectedUploads: []string{
+				"/31.1-jre/artifact-31.1-jre.pom",
+				"/31.1-jre/artifact-31.1-jre.pom.sha1",
+				"/31.1-jre/artifact-31.1-jre.pom.md5",
+				"/maven-metadata.xml",
+				"/maven-metadata.xml.sha1",
+				"/maven-metadata.xml.md5",
+			},
+			expectedMetadata: `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>artifact</artifactId>
+  <versioning>
+    <latest>33.0-SNAPSHOT</latest>
+    <release>32.0.1-jre</release>
+    <versions>
+      <version>32.0.1-jre</version>
+      <version>33.0-SNAPSHOT</version>
+      <version>31.1-jre</version>
+    </versions>
+    <lastUpdated>20230901123000</lastUpdated>
+  </versioning>
+</metadata>`,
+		},
+		{
+			name:    "checksum mismatch",
+			version: "1.2.3",
+			sourceFiles: map[string]string{
+				"/1.2.3/artifact-1.2.3.pom":      "pom",
+				"/1.2.3/artifact-1.2.3.pom.sha1": "0000",
+			},
+			expectedError: "sha1 checksum mismatch for com/example/artifact/1.2.3/artifact-1.2.3.pom: expected 0000, got acb4a94f3c944150fb89f07d87b019e224c73a27",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				content, ok := spec.sourceFiles[strings.TrimPrefix(r.URL.Path, artifactPath)]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(content))
+			}))
+			t.Cleanup(source.Close)
+
+			uploads := []string{}
+			var uploadedMetadata string
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "DESTINATION_TOKEN", r.Header.Get("Private-Token"))
+				path := strings.TrimPrefix(r.URL.Path, artifactPath)
+
+				if r.Method == http.MethodGet {
+					if path != "/maven-metadata.xml" || len(spec.existingMetadata) == 0 {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte(spec.existingMetadata))
+					return
+				}
+
+				require.Equal(t, http.MethodPut, r.Method)
+				uploads = append(uploads, path)
+				if path == "/maven-metadata.xml" {
+					body, _ := io.ReadAll(r.Body)
+					uploadedMetadata = string(body)
+				}
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{URL: source.URL},
+					Destination: config.Registry{
+						URL: destination.URL + "/",
+						Credentials: config.Credentials{
+							Token:                "DESTINATION_TOKEN",
+							AdditionalParameters: map[string]string{"header_name": "Private-Token"},
+						},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), "com.example:artifact", spec.version)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				require.Empty(t, uploads)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expectedUploads, uploads)
+			require.Equal(t, spec.expectedMetadata, uploadedMetadata)
+		})
+	}
+}
+
+func TestTransferLock(t *testing.T) {
+	registry := Registry{pkgsImport: config.Import{Destination: config.Registry{URL: "https://destination.test/maven/"}}}
+
+	expected := "https://destination.test/maven/com/example/artifact/maven-metadata.xml"
+	require.Equal(t, expected, registry.TransferLock("com.example:artifact", "1.2.3"))
+	require.Equal(t, expected, registry.TransferLock("com.example:artifact", "2.0.0:pom"))
+	require.NotEqual(t, expected, registry.TransferLock("com.example:other", "1.2.3"))
+}
+
+func TestExists(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected bool
+	}{
+		{
+			name:     "existing jar",
+			version:  "1.2.3",
+			expected: true,
+		},
+		{
+			name:     "existing pom",
+			version:  "2.0.0:pom",
+			expected: true,
+		},
+		{
+			name:     "missing version",
+			version:  "3.0.0",
+			expected: false,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodHead, r.Method)
+
+				switch strings.TrimPrefix(r.URL.Path, artifactPath) {
+				case "/1.2.3/artifact-1.2.3.jar", "/2.0.0/artifact-2.0.0.pom":
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Destination: config.Registry{URL: destination.URL},
+				},
+			}
+
+			exists, err := registry.Exists(context.Background(), transfer.NewClient(nil), "com.example:artifact", spec.version)
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, exists)
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != artifactPath+"/maven-metadata.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<metadata><groupId>com.example</groupId><artifactId>artifact</artifactId><versioning><versions><version>1.2.3</version><version>2.0.0</version></versions></versioning></metadata>`))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL},
+		},
+	}
+
+	versions, err := registry.Versions(context.Background(), transfer.NewClient(nil), "com.example:artifact")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.2.3", "2.0.0"}, versions)
+
+	versions, err = registry.Versions(context.Background(), transfer.NewClient(nil), "com.example:missing")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+}
+
+func TestPackages(t *testing.T) {
+	listings := map[string]string{
+		"/maven/com/acme/":           `<a href="../">../</a><a href="lib/">lib/</a><a href="tools/">tools/</a>`,
+		"/maven/com/acme/lib/":       `<a href="1.0.0/">1.0.0/</a><a href="maven-metadata.xml">maven-metadata.xml</a>`,
+		"/maven/com/acme/tools/":     `<a href="/maven/com/acme/tools/cli/">cli/</a><a href="https://elsewhere.test/">elsewhere</a>`,
+		"/maven/com/acme/tools/cli/": `<a href="maven-metadata.xml">maven-metadata.xml</a>`,
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listing, ok := listings[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(listing))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/maven"},
+		},
+	}
+
+	for _, prefix := range []string{"com.acme.", "com.acme.t", "com.acme:"} {
+		names, err := registry.Packages(context.Background(), transfer.NewClient(nil), prefix)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"com.acme:lib", "com.acme.tools:cli"}, names)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	poms := map[string]string{
+		"/com/example/artifact/1.2.3/artifact-1.2.3.pom": `<project>
+  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>1.0.0</version></parent>
+  <artifactId>artifact</artifactId>
+  <version>1.2.3</version>
+  <properties><lib.version>2.0.0</lib.version></properties>
+  <dependencies>
+    <dependency><groupId>com.example</groupId><artifactId>lib</artifactId><version>${lib.version}</version></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>managed</artifactId></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>bom-managed</artifactId><scope>runtime</scope></dependency>
+    <dependency><groupId>${project.groupId}</groupId><artifactId>range</artifactId><version>[1.0,2.0)</version></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>tests</artifactId><version>1.0.0</version><scope>test</scope></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>optional</artifactId><version>1.0.0</version><optional>true</optional></dependency>
+  </dependencies>
+</project>`,
+		"/com/example/parent/1.0.0/parent-1.0.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0.0</version>
+  <properties><lib.version>1.0.0</lib.version></properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency><groupId>com.example</groupId><artifactId>managed</artifactId><version>${project.version}</version></dependency>
+      <dependency><groupId>com.example</groupId><artifactId>bom</artifactId><version>3.0.0</version><type>pom</type><scope>import</scope></dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency><groupId>com.example</groupId><artifactId>inherited</artifactId><version>1.1.0</version><type>pom</type></dependency>
+  </dependencies>
+</project>`,
+		"/com/example/bom/3.0.0/bom-3.0.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>bom</artifactId>
+  <version>3.0.0</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency><groupId>com.example</groupId><artifactId>bom-managed</artifactId><version>${project.version}</version></dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := poms[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL},
+		},
+	}
+
+	dependencies, err := registry.Dependencies(context.Background(), transfer.NewClient(nil), "com.example:artifact", "1.2.3")
+
+	require.NoError(t, err)
+	require.Equal(t, []version.Dependency{
+		{Name: "com.example:parent", Version: "1.0.0:pom"},
+		{Name: "com.example:bom", Version: "3.0.0:pom"},
+		{Name: "com.example:lib", Version: "2.0.0"},
+		{Name: "com.example:managed", Version: "1.2.3"},
+		{Name: "com.example:bom-managed", Version: "3.0.0"},
+		{Name: "com.example:range", Constraint: ">=1.0, <2.0"},
+		{Name: "com.example:inherited", Version: "1.1.0:pom"},
+	}, dependencies)
+}
@@ -0,0 +1,333 @@
+package npm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/khulnasoft/packages-registry/util"
+)
+
+// Transfer copies an npm package version without the npm CLI. The version is read from the
+// source packument, its tarball is downloaded and verified and the version is published with
+// the same request as npm publish. See:
+// - https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#getpackage
+// - https://github.com/npm/libnpmpublish
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	sourceAuth := r.auth(source.Credentials)
+
+	var packument struct {
+		Versions map[string]map[string]interface{} `json:"versions"`
+	}
+	if err := client.GetJSON(ctx, r.packageUrl(source, name), sourceAuth, &packument); err != nil {
+		return err
+	}
+
+	manifest, ok := packument.Versions[version]
+	if !ok {
+		return fmt.Errorf("version %s of package %s not found in %s", version, name, source.URL)
+	}
+
+	dist, _ := manifest["dist"].(map[string]interface{})
+	tarballUrl, _ := dist["tarball"].(string)
+	if len(tarballUrl) == 0 {
+		return fmt.Errorf("version %s of package %s has no tarball in %s", version, name, source.URL)
+	}
+
+	// Like the npm CLI, the credentials are not sent to tarballs hosted elsewhere.
+	tarballAuth := sourceAuth
+	if !transfer.SameHost(tarballUrl, source.URL) {
+		tarballAuth = nil
+	}
+
+	tarball, err := client.Download(ctx, tarballUrl, tarballAuth)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	if err := r.verifyTarball(tarball, dist, path.Base(tarballUrl)); err != nil {
+		return err
+	}
+
+	body, size, err := r.publishBody(name, version, manifest, tarball)
+	if err != nil {
+		return err
+	}
+
+	destination := r.pkgsImport.Destination
+	return client.Upload(ctx, http.MethodPut, r.packageUrl(destination, name), r.auth(destination.Credentials), body, size, "Content-Type", "application/json")
+}
+
+// auth mirrors the .npmrc configuration of the scripts: the token is an _authToken or, with
+// _base64_token, an _auth value.
+func (r *Registry) auth(credentials config.Credentials) transfer.Auth {
+	credentials = transfer.ExpandCredentials(credentials)
+	if len(credentials.Token) == 0 {
+		return nil
+	}
+
+	if credentials.UseBase64Token() {
+		return transfer.HeaderAuth("Authorization", "Basic "+credentials.Token)
+	}
+
+	return transfer.BearerAuth(credentials.Token)
+}
+
+// packageUrl returns the packument url. The slash of scoped package names is escaped.
+func (r *Registry) packageUrl(registry config.Registry, name string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(registry.URL, "/"), url.PathEscape(name))
+}
+
+// verifyTarball checks the Subresource Integrity of the tarball or its sha1 shasum for older
+// packages.
+func (r *Registry) verifyTarball(tarball *transfer.File, dist map[string]interface{}, fileName string) error {
+	if integrity, _ := dist["integrity"].(string); strings.HasPrefix(integrity, "sha512-") {
+		checksum, err := tarball.Checksum("sha512")
+		if err != nil {
+			return err
+		}
+		digest, _ := hex.DecodeString(checksum)
+		if base64.StdEncoding.EncodeToString(digest) != strings.TrimPrefix(integrity, "sha512-") {
+			return fmt.Errorf("integrity mismatch for %s", fileName)
+		}
+		return nil
+	}
+
+	if shasum, _ := dist["shasum"].(string); len(shasum) != 0 {
+		return tarball.VerifyChecksum("sha1", shasum, fileName)
+	}
+
+	return nil
+}
+
+// publishBody builds the document of npm publish. Like in the scripts, the publishConfig is
+// removed so that the destination registry isn't overridden. The fields set by the source
+// registry, prefixed by an underscore, are not published. The tarball is encoded while the
+// document is sent, the returned size is the one of the whole document.
+func (r *Registry) publishBody(name, version string, manifest map[string]interface{}, tarball *transfer.File) (transfer.Body, int64, error) {
+	attachmentName := fmt.Sprintf("%s-%s.tgz", name, version)
+
+	for key := range manifest {
+		if strings.HasPrefix(key, "_") || key == "publishConfig" {
+			delete(manifest, key)
+		}
+	}
+	manifest["_id"] = fmt.Sprintf("%s@%s", name, version)
+
+	dist, _ := manifest["dist"].(map[string]interface{})
+	destinationTarballUrl := fmt.Sprintf("%s/-/%s", r.packageUrl(r.pkgsImport.Destination, name), path.Base(attachmentName))
+	publishedDist := map[string]interface{}{"tarball": destinationTarballUrl}
+	for _, key := range []string{"integrity", "shasum"} {
+		if value, ok := dist[key]; ok {
+			publishedDist[key] = value
+		}
+	}
+	manifest["dist"] = publishedDist
+
+	document, err := json.Marshal(map[string]interface{}{
+		"_id":       name,
+		"name":      name,
+		"dist-tags": map[string]string{"latest": version},
+		"versions":  map[string]interface{}{version: manifest},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	quotedAttachmentName, err := json.Marshal(attachmentName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The _attachments field is appended to the document, its data is the base64 encoded tarball.
+	head := fmt.Sprintf(`%s,"_attachments":{%s:{"content_type":"application/octet-stream","length":%d,"data":"`, document[:len(document)-1], quotedAttachmentName, tarball.Size())
+	tail := `"}}}`
+	body := func() io.Reader {
+		return io.MultiReader(strings.NewReader(head), &base64Reader{source: tarball.Reader()}, strings.NewReader(tail))
+	}
+
+	return body, int64(len(head)+len(tail)) + int64(base64.StdEncoding.EncodedLen(int(tarball.Size()))), nil
+}
+
+// base64Chunk is the number of bytes encoded at once by a base64Reader, a multiple of 3 so that
+// only the last chunk is padded.
+const base64Chunk = 3 * 1024
+
+// base64Reader encodes the content of its source in standard base64 while it's read.
+type base64Reader struct {
+	source  io.Reader
+	encoded []byte
+	done    bool
+}
+
+func (b *base64Reader) Read(p []byte) (int, error) {
+	if len(b.encoded) == 0 {
+		if b.done {
+			return 0, io.EOF
+		}
+
+		chunk := make([]byte, base64Chunk)
+		n, err := io.ReadFull(b.source, chunk)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			b.done = true
+		} else if err != nil {
+			return 0, err
+		}
+
+		b.encoded = make([]byte, base64.StdEncoding.EncodedLen(n))
+		base64.StdEncoding.Encode(b.encoded, chunk[:n])
+		if len(b.encoded) == 0 {
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, b.encoded)
+	b.encoded = b.encoded[n:]
+
+	return n, nil
+}
+
+// Exists looks up the version in the destination packument.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	versions, err := r.versions(ctx, client, r.pkgsImport.Destination, name)
+	if transfer.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := versions[version]
+	return ok, nil
+}
+
+// Versions lists the versions of the source packument.
+func (r *Registry) Versions(ctx context.Context, client *transfer.Client, name string) ([]string, error) {
+	versions, err := r.versions(ctx, client, r.pkgsImport.Source, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(versions))
+	for version := range versions {
+		result = append(result, version)
+	}
+
+	return result, nil
+}
+
+func (r *Registry) versions(ctx context.Context, client *transfer.Client, registry config.Registry, name string) (map[string]json.RawMessage, error) {
+	var packument struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := client.GetJSON(ctx, r.packageUrl(registry, name), r.auth(registry.Credentials), &packument); err != nil {
+		return nil, err
+	}
+
+	return packument.Versions, nil
+}
+
+// searchPageSize is the maximum number of results per page of the npm search API.
+const searchPageSize = 250
+
+// Packages lists the source packages with the search API, page by page. A scope prefix like
+// "@acme/" is searched as a scope, other prefixes as text. See
+// https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#get-v1search.
+func (r *Registry) Packages(ctx context.Context, client *transfer.Client, prefix string) ([]string, error) {
+	source := r.pkgsImport.Source
+
+	text := prefix
+	if scope, _, ok := strings.Cut(prefix, "/"); ok && strings.HasPrefix(scope, "@") {
+		text = "scope:" + strings.TrimPrefix(scope, "@")
+	}
+	if len(text) == 0 {
+		return nil, fmt.Errorf("npm package name patterns must start with a scope or a name prefix")
+	}
+
+	names := []string{}
+	for {
+		var page struct {
+			Objects []struct {
+				Package struct {
+					Name string `json:"name"`
+				} `json:"package"`
+			} `json:"objects"`
+			Total int `json:"total"`
+		}
+		searchUrl := fmt.Sprintf("%s/-/v1/search?text=%s&size=%d&from=%d", strings.TrimSuffix(source.URL, "/"), url.QueryEscape(text), searchPageSize, len(names))
+		if err := client.GetJSON(ctx, searchUrl, r.auth(source.Credentials), &page); err != nil {
+			return nil, err
+		}
+
+		for _, object := range page.Objects {
+			names = append(names, object.Package.Name)
+		}
+
+		if len(page.Objects) == 0 || len(names) >= page.Total {
+			return names, nil
+		}
+	}
+}
+
+// Dependencies reads the dependencies of the version in the source packument. The optional, peer
+// and development dependencies are not included.
+func (r *Registry) Dependencies(ctx context.Context, client *transfer.Client, name, packageVersion string) ([]version.Dependency, error) {
+	source := r.pkgsImport.Source
+
+	versions, err := r.versions(ctx, client, source, name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := versions[packageVersion]
+	if !ok {
+		return nil, fmt.Errorf("version %s of package %s not found in %s", packageVersion, name, source.URL)
+	}
+
+	var manifest struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	dependencies := []version.Dependency{}
+	for _, dependencyName := range util.OrderedMapKeysOf(manifest.Dependencies) {
+		if constraint, ok := dependencyConstraint(manifest.Dependencies[dependencyName]); ok {
+			dependencies = append(dependencies, version.Dependency{Name: dependencyName, Constraint: constraint})
+		}
+	}
+
+	return dependencies, nil
+}
+
+// dependencyConstraint converts the range of a dependency into a constraint. The ranges that don't
+// select a registry version, like urls, git repositories, aliases and tags other than latest, are
+// ignored.
+func dependencyConstraint(spec string) (string, bool) {
+	spec = strings.TrimSpace(spec)
+	if len(spec) == 0 || spec == "latest" {
+		return version.All, true
+	}
+
+	// A tag starts with a letter, a range can only start with a v or an x wildcard.
+	first := unicode.ToLower(rune(spec[0]))
+	if strings.ContainsAny(spec, ":/") || (unicode.IsLetter(first) && first != 'v' && first != 'x') {
+		return "", false
+	}
+
+	return spec, true
+}
@@ -0,0 +1,284 @@
+package npm
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	t.Setenv("NPM_SOURCE_TOKEN", "SOURCE_TOKEN")
+
+	tarball := []byte("tarball content")
+	digest := sha512.Sum512(tarball)
+	validIntegrity := "sha512-" + base64.StdEncoding.EncodeToString(digest[:])
+
+	tests := []struct {
+		name          string
+		packageName   string
+		version       string
+		integrity     string
+		expectedError string
+	}{
+		{
+			name:        "package",
+			packageName: "package",
+			version:     "1.2.3",
+			integrity:   validIntegrity,
+		},
+		{
+			name:        "scoped package",
+			packageName: "@scope/package",
+			version:     "1.2.3",
+			integrity:   validIntegrity,
+		},
+		{
+			name:          "unknown version",
+			packageName:   "package",
+			version:       "4.5.6",
+			integrity:     validIntegrity,
+			expectedError: "version 4.5.6 of package package not found in <source>",
+		},
+		{
+			name:          "integrity mismatch",
+			packageName:   "package",
+			version:       "1.2.3",
+			integrity:     "sha512-invalid",
+			expectedError: "integrity mismatch for package-1.2.3.tgz",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			var source *httptest.Server
+			source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer SOURCE_TOKEN", r.Header.Get("Authorization"))
+
+				switch r.URL.EscapedPath() {
+				case "/" + escapedName(spec.packageName):
+					fmt.Fprintf(w, `{"versions":{"1.2.3":{"name":%q,"version":"1.2.3","_npmUser":{"name":"someone"},"publishConfig":{"registry":"http://other.test"},"dist":{"integrity":%q,"tarball":"%s/tarballs/package-1.2.3.tgz"}}}}`, spec.packageName, spec.integrity, source.URL)
+				case "/tarballs/package-1.2.3.tgz":
+					w.Write(tarball)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(source.Close)
+
+			var published map[string]interface{}
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(t, "/"+escapedName(spec.packageName), r.URL.EscapedPath())
+				require.Equal(t, "Basic BASE64_TOKEN", r.Header.Get("Authorization"))
+				body, _ := io.ReadAll(r.Body)
+				require.NoError(t, json.Unmarshal(body, &published))
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{
+						URL:         source.URL + "/",
+						Credentials: config.Credentials{Token: "${NPM_SOURCE_TOKEN}"},
+					},
+					Destination: config.Registry{
+						URL: destination.URL,
+						Credentials: config.Credentials{
+							Token:                "BASE64_TOKEN",
+							AdditionalParameters: map[string]string{config.Base64TokenKey: "1"},
+						},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), spec.packageName, spec.version)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, strings.Replace(spec.expectedError, "<source>", source.URL+"/", 1))
+				require.Nil(t, published)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.packageName, published["name"])
+			require.Equal(t, map[string]interface{}{"latest": "1.2.3"}, published["dist-tags"])
+
+			manifest := published["versions"].(map[string]interface{})["1.2.3"].(map[string]interface{})
+			require.Equal(t, spec.packageName+"@1.2.3", manifest["_id"])
+			require.NotContains(t, manifest, "_npmUser")
+			require.NotContains(t, manifest, "publishConfig")
+			require.Equal(t, map[string]interface{}{
+				"integrity": validIntegrity,
+				"tarball":   fmt.Sprintf("%s/%s/-/package-1.2.3.tgz", destination.URL, escapedName(spec.packageName)),
+			}, manifest["dist"])
+
+			attachment := published["_attachments"].(map[string]interface{})[spec.packageName+"-1.2.3.tgz"].(map[string]interface{})
+			require.Equal(t, base64.StdEncoding.EncodeToString(tarball), attachment["data"])
+			require.Equal(t, float64(len(tarball)), attachment["length"])
+		})
+	}
+}
+
+func TestBase64Reader(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, base64Chunk - 1, base64Chunk, base64Chunk + 1, 3*base64Chunk + 2} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			content := []byte(strings.Repeat("x", size))
+
+			encoded, err := io.ReadAll(&base64Reader{source: strings.NewReader(string(content))})
+			require.NoError(t, err)
+			require.Equal(t, base64.StdEncoding.EncodeToString(content), string(encoded))
+		})
+	}
+}
+
+func escapedName(name string) string {
+	if name == "@scope/package" {
+		return "@scope%2Fpackage"
+	}
+	return name
+}
+
+func TestExists(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageName string
+		version     string
+		expected    bool
+	}{
+		{
+			name:        "existing version",
+			packageName: "package",
+			version:     "1.2.3",
+			expected:    true,
+		},
+		{
+			name:        "missing version",
+			packageName: "package",
+			version:     "2.0.0",
+			expected:    false,
+		},
+		{
+			name:        "missing package",
+			packageName: "missing",
+			version:     "1.2.3",
+			expected:    false,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer DESTINATION_TOKEN", r.Header.Get("Authorization"))
+
+				if r.URL.Path != "/package" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(`{"name":"package","versions":{"1.2.3":{}}}`))
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Destination: config.Registry{
+						URL:         destination.URL,
+						Credentials: config.Credentials{Token: "DESTINATION_TOKEN"},
+					},
+				},
+			}
+
+			exists, err := registry.Exists(context.Background(), transfer.NewClient(nil), spec.packageName, spec.version)
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, exists)
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer SOURCE_TOKEN", r.Header.Get("Authorization"))
+		require.Equal(t, "/@scope%2Fpackage", r.URL.RawPath)
+		w.Write([]byte(`{"name":"@scope/package","versions":{"1.2.3":{},"2.0.0":{}}}`))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{
+				URL:         source.URL,
+				Credentials: config.Credentials{Token: "SOURCE_TOKEN"},
+			},
+		},
+	}
+
+	versions, err := registry.Versions(context.Background(), transfer.NewClient(nil), "@scope/package")
+
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"1.2.3", "2.0.0"}, versions)
+}
+
+func TestPackages(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/-/v1/search", r.URL.Path)
+		require.Equal(t, "scope:acme", r.URL.Query().Get("text"))
+
+		switch r.URL.Query().Get("from") {
+		case "0":
+			w.Write([]byte(`{"objects":[{"package":{"name":"@acme/first"}},{"package":{"name":"@acme/second"}}],"total":3}`))
+		case "2":
+			w.Write([]byte(`{"objects":[{"package":{"name":"@acme/third"}}],"total":3}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.RawQuery)
+		}
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL},
+		},
+	}
+
+	names, err := registry.Packages(context.Background(), transfer.NewClient(nil), "@acme/")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"@acme/first", "@acme/second", "@acme/third"}, names)
+}
+
+func TestDependencies(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"versions":{"1.2.3":{"dependencies":{"caret":"^1.2.0","latest":"latest","empty":"","git":"git+https://example.test/git.git","alias":"npm:other@1","tag":"next","range":">=1.0.0 <2"},"devDependencies":{"dev":"1.0.0"}}}}`))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL},
+		},
+	}
+
+	dependencies, err := registry.Dependencies(context.Background(), transfer.NewClient(nil), "package", "1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, []version.Dependency{
+		{Name: "caret", Constraint: "^1.2.0"},
+		{Name: "empty", Constraint: "*"},
+		{Name: "latest", Constraint: "*"},
+		{Name: "range", Constraint: ">=1.0.0 <2"},
+	}, dependencies)
+
+	_, err = registry.Dependencies(context.Background(), transfer.NewClient(nil), "package", "2.0.0")
+	require.EqualError(t, err, "version 2.0.0 of package package not found in "+source.URL)
+}
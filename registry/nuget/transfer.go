@@ -0,0 +1,256 @@
+package nuget
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"golang.org/x/exp/slices"
+)
+
+// The NuGet V3 resources used by the in-process imports, see
+// https://learn.microsoft.com/en-us/nuget/api/overview#resources-and-schema.
+const (
+	packageBaseAddressResource = "PackageBaseAddress/3.0.0"
+	packagePublishResource     = "PackagePublish/2.0.0"
+	searchQueryResource        = "SearchQueryService"
+)
+
+// searchPageSize is the maximum number of results per page of the search resource.
+const searchPageSize = 1000
+
+// Transfer copies a NuGet package version without the nuget CLI. The registry urls are V3
+// service indexes. The .nupkg file is downloaded from the package content resource and pushed
+// to the publish resource. See:
+// - https://learn.microsoft.com/en-us/nuget/api/package-base-address-resource#download-package-content-nupkg
+// - https://learn.microsoft.com/en-us/nuget/api/package-publish-resource#push-a-package
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	destination := r.pkgsImport.Destination
+
+	baseAddress, err := r.resourceUrl(ctx, client, source, packageBaseAddressResource)
+	if err != nil {
+		return err
+	}
+
+	id, lowerVersion := strings.ToLower(name), strings.ToLower(version)
+	nupkgUrl := fmt.Sprintf("%s/%s/%s/%s.%s.nupkg", strings.TrimSuffix(baseAddress, "/"), id, lowerVersion, id, lowerVersion)
+	nupkg, err := client.Download(ctx, nupkgUrl, r.resourceAuth(source, nupkgUrl))
+	if err != nil {
+		return err
+	}
+	defer nupkg.Close()
+
+	publishUrl, err := r.resourceUrl(ctx, client, destination, packagePublishResource)
+	if err != nil {
+		return err
+	}
+
+	body, size, contentType, err := transfer.MultipartForm(nil, "package", "package.nupkg", nupkg)
+	if err != nil {
+		return err
+	}
+
+	// Like the nuget CLI, the API key is pushed to the publish resource wherever it is hosted:
+	// nuget.org publishes on another host than its service index.
+	return client.Upload(ctx, http.MethodPut, publishUrl, r.auth(destination.Credentials), body, size, "Content-Type", contentType)
+}
+
+func (r *Registry) resourceUrl(ctx context.Context, client *transfer.Client, registry config.Registry, resourceType string) (string, error) {
+	var serviceIndex struct {
+		Resources []struct {
+			Id   string `json:"@id"`
+			Type string `json:"@type"`
+		} `json:"resources"`
+	}
+	if err := client.GetJSON(ctx, registry.URL, r.auth(registry.Credentials), &serviceIndex); err != nil {
+		return "", err
+	}
+
+	for _, resource := range serviceIndex.Resources {
+		if resource.Type == resourceType {
+			return resource.Id, nil
+		}
+	}
+
+	return "", fmt.Errorf("the NuGet service index %s has no %s resource", registry.URL, resourceType)
+}
+
+// auth uses the username and the token like the nuget sources of the scripts. Without a
+// username, the token is sent as an API key.
+func (r *Registry) auth(credentials config.Credentials) transfer.Auth {
+	credentials = transfer.ExpandCredentials(credentials)
+	if len(credentials.Token) == 0 {
+		return nil
+	}
+
+	if username := credentials.AdditionalParameters["username"]; len(username) != 0 {
+		return transfer.BasicAuth(username, credentials.Token)
+	}
+
+	return transfer.HeaderAuth("X-NuGet-ApiKey", credentials.Token)
+}
+
+// resourceAuth returns the authentication of the registry for a url of one of its resources. Like
+// for npm tarballs, the credentials are not sent to resources hosted elsewhere than the service
+// index.
+func (r *Registry) resourceAuth(registry config.Registry, resourceUrl string) transfer.Auth {
+	if !transfer.SameHost(resourceUrl, registry.URL) {
+		return nil
+	}
+
+	return r.auth(registry.Credentials)
+}
+
+// Exists looks up the version in the destination package content resource.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	versions, err := r.versions(ctx, client, r.pkgsImport.Destination, name)
+	if transfer.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range versions {
+		if strings.EqualFold(v, version) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Versions lists the versions of the source package content resource.
+func (r *Registry) Versions(ctx context.Context, client *transfer.Client, name string) ([]string, error) {
+	return r.versions(ctx, client, r.pkgsImport.Source, name)
+}
+
+// versions enumerates the versions of a package, see
+// https://learn.microsoft.com/en-us/nuget/api/package-base-address-resource#enumerate-package-versions.
+func (r *Registry) versions(ctx context.Context, client *transfer.Client, registry config.Registry, name string) ([]string, error) {
+	baseAddress, err := r.resourceUrl(ctx, client, registry, packageBaseAddressResource)
+	if err != nil {
+		return nil, err
+	}
+
+	var index struct {
+		Versions []string `json:"versions"`
+	}
+	versionsUrl := fmt.Sprintf("%s/%s/index.json", strings.TrimSuffix(baseAddress, "/"), strings.ToLower(name))
+	if err := client.GetJSON(ctx, versionsUrl, r.resourceAuth(registry, versionsUrl), &index); err != nil {
+		return nil, err
+	}
+
+	return index.Versions, nil
+}
+
+// Packages lists the source packages with the search resource, page by page. See
+// https://learn.microsoft.com/en-us/nuget/api/search-query-service-resource.
+func (r *Registry) Packages(ctx context.Context, client *transfer.Client, prefix string) ([]string, error) {
+	source := r.pkgsImport.Source
+
+	searchUrl, err := r.resourceUrl(ctx, client, source, searchQueryResource)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for {
+		var page struct {
+			TotalHits int `json:"totalHits"`
+			Data      []struct {
+				Id string `json:"id"`
+			} `json:"data"`
+		}
+		pageUrl := fmt.Sprintf("%s?q=%s&skip=%d&take=%d&prerelease=true&semVerLevel=2.0.0", searchUrl, url.QueryEscape(prefix), len(ids), searchPageSize)
+		if err := client.GetJSON(ctx, pageUrl, r.resourceAuth(source, pageUrl), &page); err != nil {
+			return nil, err
+		}
+
+		for _, data := range page.Data {
+			ids = append(ids, data.Id)
+		}
+
+		if len(page.Data) == 0 || len(ids) >= page.TotalHits {
+			return ids, nil
+		}
+	}
+}
+
+// nuspec is the part of the manifest of a package with its dependencies, see
+// https://learn.microsoft.com/en-us/nuget/reference/nuspec#dependencies-element.
+type nuspec struct {
+	Metadata struct {
+		Dependencies struct {
+			Dependencies []nuspecDependency `xml:"dependency"`
+			Groups       []struct {
+				Dependencies []nuspecDependency `xml:"dependency"`
+			} `xml:"group"`
+		} `xml:"dependencies"`
+	} `xml:"metadata"`
+}
+
+type nuspecDependency struct {
+	Id      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// Dependencies reads the dependencies of all the framework groups of the source .nuspec file. See
+// https://learn.microsoft.com/en-us/nuget/api/package-base-address-resource#download-package-manifest-nuspec.
+func (r *Registry) Dependencies(ctx context.Context, client *transfer.Client, name, packageVersion string) ([]version.Dependency, error) {
+	source := r.pkgsImport.Source
+
+	baseAddress, err := r.resourceUrl(ctx, client, source, packageBaseAddressResource)
+	if err != nil {
+		return nil, err
+	}
+
+	id, lowerVersion := strings.ToLower(name), strings.ToLower(packageVersion)
+	nuspecUrl := fmt.Sprintf("%s/%s/%s/%s.nuspec", strings.TrimSuffix(baseAddress, "/"), id, lowerVersion, id)
+	content, err := client.Get(ctx, nuspecUrl, r.resourceAuth(source, nuspecUrl))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest nuspec
+	if err := xml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid nuspec file %s: %w", nuspecUrl, err)
+	}
+
+	nuspecDependencies := manifest.Metadata.Dependencies.Dependencies
+	for _, group := range manifest.Metadata.Dependencies.Groups {
+		nuspecDependencies = append(nuspecDependencies, group.Dependencies...)
+	}
+
+	dependencies := []version.Dependency{}
+	for _, d := range nuspecDependencies {
+		// The ids are compared without case.
+		dependency := version.Dependency{Name: r.NormalizeName(d.Id)}
+		if constraint, ok := version.FromInterval(d.Version); ok {
+			dependency.Constraint = constraint
+		} else if len(d.Version) != 0 {
+			// A version alone is a minimum version and NuGet picks the lowest applicable one.
+			dependency.Version = d.Version
+		} else {
+			dependency.Constraint = version.All
+		}
+
+		if !slices.Contains(dependencies, dependency) {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// NormalizeName returns the lowercase id, the ids are case insensitive.
+func (r *Registry) NormalizeName(name string) string {
+	return strings.ToLower(name)
+}
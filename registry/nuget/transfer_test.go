@@ -0,0 +1,268 @@
+package nuget
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	t.Setenv("NUGET_API_KEY", "API_KEY")
+
+	tests := []struct {
+		name          string
+		version       string
+		resources     string
+		expectedError string
+	}{
+		{
+			name:      "existing version",
+			version:   "1.2.3-Beta",
+			resources: `{"@id":"<destination>/publish","@type":"PackagePublish/2.0.0"}`,
+		},
+		{
+			name:          "missing version",
+			version:       "4.5.6",
+			resources:     `{"@id":"<destination>/publish","@type":"PackagePublish/2.0.0"}`,
+			expectedError: "GET <source>/flat/package.id/4.5.6/package.id.4.5.6.nupkg returned 404 Not Found",
+		},
+		{
+			name:          "no publish resource",
+			version:       "1.2.3-Beta",
+			resources:     `{"@id":"<destination>/search","@type":"SearchQueryService"}`,
+			expectedError: "the NuGet service index <destination>/index.json has no PackagePublish/2.0.0 resource",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			var source *httptest.Server
+			source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				username, password, _ := r.BasicAuth()
+				require.Equal(t, "user:SOURCE_TOKEN", username+":"+password)
+
+				switch r.URL.Path {
+				case "/index.json":
+					fmt.Fprintf(w, `{"resources":[{"@id":"%s/flat/","@type":"PackageBaseAddress/3.0.0"}]}`, source.URL)
+				case "/flat/package.id/1.2.3-beta/package.id.1.2.3-beta.nupkg":
+					w.Write([]byte("nupkg"))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(source.Close)
+
+			var pushed string
+			var destination *httptest.Server
+			destination = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "API_KEY", r.Header.Get("X-NuGet-ApiKey"))
+
+				switch r.URL.Path {
+				case "/index.json":
+					fmt.Fprintf(w, `{"resources":[%s]}`, strings.ReplaceAll(spec.resources, "<destination>", destination.URL))
+				case "/publish":
+					require.Equal(t, http.MethodPut, r.Method)
+					_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+					require.NoError(t, err)
+					form, err := multipart.NewReader(r.Body, params["boundary"]).ReadForm(1024)
+					require.NoError(t, err)
+					file, err := form.File["package"][0].Open()
+					require.NoError(t, err)
+					content, _ := io.ReadAll(file)
+					pushed = string(content)
+					w.WriteHeader(http.StatusCreated)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{
+						URL: source.URL + "/index.json",
+						Credentials: config.Credentials{
+							Token:                "SOURCE_TOKEN",
+							AdditionalParameters: map[string]string{"username": "user"},
+						},
+					},
+					Destination: config.Registry{
+						URL:         destination.URL + "/index.json",
+						Credentials: config.Credentials{Token: "$NUGET_API_KEY"},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), "Package.Id", spec.version)
+
+			if len(spec.expectedError) != 0 {
+				expectedError := strings.Replace(spec.expectedError, "<source>", source.URL, 1)
+				require.EqualError(t, err, strings.Replace(expectedError, "<destination>", destination.URL, 1))
+				require.Empty(t, pushed)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "nupkg", pushed)
+		})
+	}
+}
+
+func TestTransferFromOtherHost(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Empty(t, r.Header.Get("Authorization"))
+		require.Equal(t, "/flat/package.id/1.2.3/package.id.1.2.3.nupkg", r.URL.Path)
+		w.Write([]byte("nupkg"))
+	}))
+	t.Cleanup(files.Close)
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		require.Equal(t, "user:SOURCE_TOKEN", username+":"+password)
+		fmt.Fprintf(w, `{"resources":[{"@id":"%s/flat/","@type":"PackageBaseAddress/3.0.0"}]}`, files.URL)
+	}))
+	t.Cleanup(source.Close)
+
+	var pushed bool
+	var destination *httptest.Server
+	destination = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			fmt.Fprintf(w, `{"resources":[{"@id":"%s/publish","@type":"PackagePublish/2.0.0"}]}`, destination.URL)
+		case "/publish":
+			pushed = true
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(destination.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{
+				URL: source.URL + "/index.json",
+				Credentials: config.Credentials{
+					Token:                "SOURCE_TOKEN",
+					AdditionalParameters: map[string]string{"username": "user"},
+				},
+			},
+			Destination: config.Registry{URL: destination.URL + "/index.json"},
+		},
+	}
+
+	require.NoError(t, registry.Transfer(context.Background(), transfer.NewClient(nil), "Package.Id", "1.2.3"))
+	require.True(t, pushed)
+}
+
+func TestVersions(t *testing.T) {
+	var source *httptest.Server
+	source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			fmt.Fprintf(w, `{"resources":[{"@id":"%s/flat/","@type":"PackageBaseAddress/3.0.0"}]}`, source.URL)
+		case "/flat/package.id/index.json":
+			w.Write([]byte(`{"versions":["1.2.3","2.0.0-beta"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/index.json"},
+		},
+	}
+
+	versions, err := registry.Versions(context.Background(), transfer.NewClient(nil), "Package.Id")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.2.3", "2.0.0-beta"}, versions)
+}
+
+func TestPackages(t *testing.T) {
+	var source *httptest.Server
+	source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			fmt.Fprintf(w, `{"resources":[{"@id":"%s/search","@type":"SearchQueryService"}]}`, source.URL)
+		case "/search":
+			require.Equal(t, "Acme.", r.URL.Query().Get("q"))
+			if r.URL.Query().Get("skip") == "0" {
+				w.Write([]byte(`{"totalHits":2,"data":[{"id":"Acme.First"}]}`))
+			} else {
+				w.Write([]byte(`{"totalHits":2,"data":[{"id":"Acme.Second"}]}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/index.json"},
+		},
+	}
+
+	ids, err := registry.Packages(context.Background(), transfer.NewClient(nil), "Acme.")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"Acme.First", "Acme.Second"}, ids)
+}
+
+func TestDependencies(t *testing.T) {
+	var source *httptest.Server
+	source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			fmt.Fprintf(w, `{"resources":[{"@id":"%s/flat/","@type":"PackageBaseAddress/3.0.0"}]}`, source.URL)
+		case "/flat/package.id/1.2.3/package.id.nuspec":
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Package.Id</id>
+    <version>1.2.3</version>
+    <dependencies>
+      <group targetFramework="net6.0">
+        <dependency id="Newtonsoft.Json" version="13.0.1" />
+        <dependency id="Range.Package" version="[1.0, 2.0)" />
+      </group>
+      <group targetFramework="netstandard2.0">
+        <dependency id="Newtonsoft.Json" version="13.0.1" />
+        <dependency id="Any.Package" />
+      </group>
+    </dependencies>
+  </metadata>
+</package>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/index.json"},
+		},
+	}
+
+	dependencies, err := registry.Dependencies(context.Background(), transfer.NewClient(nil), "Package.Id", "1.2.3")
+
+	require.NoError(t, err)
+	require.Equal(t, []version.Dependency{
+		{Name: "newtonsoft.json", Version: "13.0.1"},
+		{Name: "range.package", Constraint: ">=1.0, <2.0"},
+		{Name: "any.package", Constraint: "*"},
+	}, dependencies)
+}
@@ -0,0 +1,149 @@
+// Package oci is a set of simple functions that provide all the custom parts to handle
+// container image imports from an OCI (docker) registry A to an OCI registry B.
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents an OCI registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new OCI registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for container image imports.
+// The debug variant is used because it provides a shell.
+func (r *Registry) ImageName() string {
+	return "gcr.io/go-containerregistry/crane:debug"
+}
+
+const digestPrefix = "sha256:"
+
+// AdditionalEnvVars returns the additional environment variables.
+// The version is either a tag or a digest. The image reference uses the right separator for each case.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	separator := ":"
+	if strings.HasPrefix(version, digestPrefix) {
+		separator = "@"
+	}
+
+	return map[string]string{"PACKAGE_IMAGE_REFERENCE": fmt.Sprintf("%s%s%s", name, separator, version)}
+}
+
+// Scripts returns the script lines to execute a container image import.
+// crane copies the manifests, including multi-arch indexes, and all the blobs. See:
+// - https://github.com/google/go-containerregistry/blob/main/cmd/crane/doc/crane_auth_login.md
+// - https://github.com/google/go-containerregistry/blob/main/cmd/crane/doc/crane_copy.md
+func (r *Registry) Scripts() ([]string, error) {
+	source, err := url.Parse(r.pkgsImport.Source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := url.Parse(r.pkgsImport.Destination.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make([]string, 0, 3)
+
+	if len(r.pkgsImport.Source.Credentials.Token) != 0 {
+		scripts = append(scripts, r.loginScript(source.Host, r.pkgsImport.Source.Credentials))
+	}
+	scripts = append(scripts, r.loginScript(destination.Host, r.pkgsImport.Destination.Credentials))
+	scripts = append(scripts, fmt.Sprintf(`crane copy "%s/$PACKAGE_IMAGE_REFERENCE" "%s/$PACKAGE_IMAGE_REFERENCE"`, r.repositoryPrefix(source), r.repositoryPrefix(destination)))
+
+	return scripts, nil
+}
+
+// repositoryPrefix returns the registry host with the optional namespace path, without the URL scheme.
+func (r *Registry) repositoryPrefix(address *url.URL) string {
+	return strings.TrimSuffix(address.Host+address.Path, "/")
+}
+
+func (r *Registry) loginScript(host string, credentials config.Credentials) string {
+	return fmt.Sprintf(`crane auth login %s --username "%s" --password "%s"`, host, credentials.AdditionalParameters["username"], credentials.Token)
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pulling-manifests
+var (
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+	digestRegexp     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !repositoryRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid repository path. It must be lower case and only contain alphanumeric characters, separators and /.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !tagRegexp.MatchString(version) && !digestRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid image version. It must be a tag or a sha256 digest.", version)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("OCI credentials require a token and a username in authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if len(credentials.Token) == 0 || len(credentials.AdditionalParameters["username"]) != 0 {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
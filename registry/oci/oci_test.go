@@ -0,0 +1,229 @@
+package oci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	tests := []struct {
+		name                string
+		sourceUrl           string
+		sourceToken         string
+		sourceUsername      string
+		destinationUrl      string
+		destinationToken    string
+		destinationUsername string
+		expectedScripts     []string
+	}{
+		{
+			name:                "public source",
+			sourceUrl:           "https://source.test",
+			destinationUrl:      "https://destination.test/my-group/mirror/",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`crane auth login destination.test --username "username_for_destination" --password "TOKEN_FOR_DESTINATION"`,
+				`crane copy "source.test/$PACKAGE_IMAGE_REFERENCE" "destination.test/my-group/mirror/$PACKAGE_IMAGE_REFERENCE"`,
+			},
+		},
+		{
+			name:                "private source",
+			sourceUrl:           "docker://source.test:5000/images",
+			sourceToken:         "TOKEN_FOR_SOURCE",
+			sourceUsername:      "username_for_source",
+			destinationUrl:      "https://destination.test",
+			destinationToken:    "TOKEN_FOR_DESTINATION",
+			destinationUsername: "username_for_destination",
+			expectedScripts: []string{
+				`crane auth login source.test:5000 --username "username_for_source" --password "TOKEN_FOR_SOURCE"`,
+				`crane auth login destination.test --username "username_for_destination" --password "TOKEN_FOR_DESTINATION"`,
+				`crane copy "source.test:5000/images/$PACKAGE_IMAGE_REFERENCE" "destination.test/$PACKAGE_IMAGE_REFERENCE"`,
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{
+				Source: config.Registry{
+					URL: spec.sourceUrl,
+					Credentials: config.Credentials{
+						Token:                spec.sourceToken,
+						AdditionalParameters: map[string]string{"username": spec.sourceUsername},
+					},
+				},
+				Destination: config.Registry{
+					URL: spec.destinationUrl,
+					Credentials: config.Credentials{
+						Token:                spec.destinationToken,
+						AdditionalParameters: map[string]string{"username": spec.destinationUsername},
+					},
+				},
+			}
+
+			registry := Registry{
+				pkgsImport: pkgsImport,
+			}
+
+			scripts, err := registry.Scripts()
+			assert.NoError(t, err)
+			require.Equal(t, spec.expectedScripts, scripts)
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"username": "user",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "oci",
+			},
+		},
+		{
+			name: "with registries with correct credentials and images",
+			pkgsImport: config.Import{
+				Type:        "docker",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"library/alpine":         {"3.18", "latest", "sha256:" + strings.Repeat("a1", 32)},
+				"my-group/my_app/server": {"v1.2.3-rc.1"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "oci",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "oci",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with upper case repository",
+			errorMessage: "Library/Alpine is an invalid repository path. It must be lower case and only contain alphanumeric characters, separators and /.",
+			pkgsImport: config.Import{
+				Type:        "oci",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"Library/Alpine": {"latest"},
+			},
+		},
+		{
+			name:         "with invalid tag",
+			errorMessage: ".hidden is an invalid image version. It must be a tag or a sha256 digest.",
+			pkgsImport: config.Import{
+				Type:        "oci",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"library/alpine": {".hidden"},
+			},
+		},
+		{
+			name:         "with truncated digest",
+			errorMessage: "sha256:abc is an invalid image version. It must be a tag or a sha256 digest.",
+			pkgsImport: config.Import{
+				Type:        "oci",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"library/alpine": {"sha256:abc"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "gcr.io/go-containerregistry/crane:debug", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a1", 32)
+
+	tests := []struct {
+		name     string
+		version  string
+		expected map[string]string
+	}{
+		{
+			name:     "with a tag",
+			version:  "3.18",
+			expected: map[string]string{"PACKAGE_IMAGE_REFERENCE": "library/alpine:3.18"},
+		},
+		{
+			name:     "with a digest",
+			version:  digest,
+			expected: map[string]string{"PACKAGE_IMAGE_REFERENCE": "library/alpine@" + digest},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			require.Equal(t, spec.expected, new(Registry).AdditionalEnvVars("library/alpine", spec.version))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Package pub is a set of simple functions that provide all the custom parts to handle
+// Dart and Flutter package imports from pub repository A to pub repository B.
+package pub
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a hosted pub repository given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new pub registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validatePackages(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for pub package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The pub scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+// importScript resolves the package version in the source repository, downloads its archive and
+// publishes it with the destination upload flow. See pub_import.py for the details.
+//
+//go:embed pub_import.py
+var importScript string
+
+const importScriptFile = "pub_import.py"
+
+// Scripts returns the script lines to execute a pub package import.
+// dart pub publish can't publish an existing archive, so the repositories are accessed directly
+// with the hosted pub repository specification:
+// https://github.com/dart-lang/pub/blob/master/doc/repository-spec-v2.md
+func (r *Registry) Scripts() ([]string, error) {
+	return []string{
+		fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript),
+		fmt.Sprintf(`export PUB_SOURCE_TOKEN="%s"`, r.pkgsImport.Source.Credentials.Token),
+		fmt.Sprintf(`export PUB_DESTINATION_TOKEN="%s"`, r.pkgsImport.Destination.Credentials.Token),
+		fmt.Sprintf(`python3 %s "%s" "%s" "$PACKAGE_NAME" "$PACKAGE_VERSION"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL),
+	}, nil
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://dart.dev/tools/pub/pubspec#name and https://dart.dev/tools/pub/pubspec#version
+var (
+	pubPackageNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	pubVersionRegexp     = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !pubPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid pub package name. It must be lower case and only contain alphanumeric characters or _.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !pubVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid pub package version. It must be a semantic version like 1.2.3.", version)
+	}
+	return nil
+}
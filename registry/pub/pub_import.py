@@ -0,0 +1,96 @@
+"""Copies a package version from a hosted pub repository to another one.
+
+Usage: python3 pub_import.py <source url> <destination url> <name> <version>
+
+Tokens are read from the PUB_SOURCE_TOKEN and PUB_DESTINATION_TOKEN environment variables.
+See https://github.com/dart-lang/pub/blob/master/doc/repository-spec-v2.md.
+"""
+
+import hashlib
+import json
+import os
+import sys
+import urllib.error
+import urllib.parse
+import urllib.request
+import uuid
+
+ACCEPT = "application/vnd.pub.v2+json"
+
+
+class NoRedirect(urllib.request.HTTPRedirectHandler):
+    def redirect_request(self, req, fp, code, msg, headers, newurl):
+        return None
+
+
+def open_url(url, repository, token, data=None, headers=None, method="GET", follow_redirects=True):
+    all_headers = {"User-Agent": "pkgs_importer", "Accept": ACCEPT}
+    all_headers.update(headers or {})
+    # Tokens are only sent to the repository, archives and uploads can be on other hosts.
+    if token and url.startswith(repository + "/"):
+        all_headers["Authorization"] = "Bearer " + token
+    request = urllib.request.Request(url, data=data, headers=all_headers, method=method)
+    if follow_redirects:
+        return urllib.request.urlopen(request)
+    return urllib.request.build_opener(NoRedirect).open(request)
+
+
+def read_json(url, repository, token):
+    with open_url(url, repository, token) as response:
+        return json.loads(response.read())
+
+
+def multipart(fields, file_name, content):
+    boundary = uuid.uuid4().hex
+    body = b""
+    for name, value in fields.items():
+        body += ('--%s\r\nContent-Disposition: form-data; name="%s"\r\n\r\n%s\r\n' % (boundary, name, value)).encode()
+    body += (
+        '--%s\r\nContent-Disposition: form-data; name="file"; filename="%s"\r\n'
+        "Content-Type: application/octet-stream\r\n\r\n" % (boundary, file_name)
+    ).encode()
+    body += content + ("\r\n--%s--\r\n" % boundary).encode()
+    return body, "multipart/form-data; boundary=" + boundary
+
+
+def upload(destination, token, archive):
+    new_version = read_json(destination + "/api/packages/versions/new", destination, token)
+    body, content_type = multipart(new_version.get("fields", {}), "package.tar.gz", archive)
+    try:
+        response = open_url(
+            new_version["url"], destination, token, data=body, headers={"Content-Type": content_type}, method="POST", follow_redirects=False
+        )
+        location = response.headers.get("Location")
+    except urllib.error.HTTPError as error:
+        if error.code not in (301, 302, 303, 307, 308):
+            raise
+        location = error.headers.get("Location")
+    if not location:
+        sys.exit("the upload to %s didn't return a finalization location" % new_version["url"])
+
+    result = read_json(urllib.parse.urljoin(new_version["url"], location), destination, token)
+    if "success" not in result:
+        sys.exit("the upload failed: %s" % json.dumps(result))
+    print(result["success"].get("message", "package version uploaded"))
+
+
+def main(source, destination, name, version):
+    source_token = os.environ.get("PUB_SOURCE_TOKEN")
+    destination_token = os.environ.get("PUB_DESTINATION_TOKEN")
+    source, destination = source.rstrip("/"), destination.rstrip("/")
+
+    url = "%s/api/packages/%s/versions/%s" % (source, name, urllib.parse.quote(version))
+    package_version = read_json(url, source, source_token)
+    archive_url = urllib.parse.urljoin(url, package_version["archive_url"])
+    with open_url(archive_url, source, source_token) as response:
+        archive = response.read()
+
+    checksum = package_version.get("archive_sha256")
+    if checksum and hashlib.sha256(archive).hexdigest() != checksum:
+        sys.exit("checksum mismatch for package %s version %s" % (name, version))
+
+    upload(destination, destination_token, archive)
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:5])
@@ -0,0 +1,106 @@
+package pub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "https://pub.dev",
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/api/pub",
+			Credentials: config.Credentials{
+				Token: "TOKEN_FOR_DESTINATION",
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 4)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > pub_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `"/api/packages/versions/new"`)
+	require.Equal(t, `export PUB_SOURCE_TOKEN=""`, scripts[1])
+	require.Equal(t, `export PUB_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`, scripts[2])
+	require.Equal(t, `python3 pub_import.py "https://pub.dev" "https://destination.test/api/pub" "$PACKAGE_NAME" "$PACKAGE_VERSION"`, scripts[3])
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with no packages",
+		},
+		{
+			name: "with valid packages",
+			pkgs: map[string][]string{
+				"http":          {"1.1.0"},
+				"flutter_bloc":  {"8.1.3", "9.0.0-dev.1", "8.1.4+1"},
+				"_private_tool": {"0.0.1"},
+			},
+		},
+		{
+			name:         "with package name with a dash",
+			errorMessage: "flutter-bloc is an invalid pub package name. It must be lower case and only contain alphanumeric characters or _.",
+			pkgs: map[string][]string{
+				"flutter-bloc": {"8.1.3"},
+			},
+		},
+		{
+			name:         "with package name starting with a digit",
+			errorMessage: "2d_engine is an invalid pub package name.",
+			pkgs: map[string][]string{
+				"2d_engine": {"1.0.0"},
+			},
+		},
+		{
+			name:         "with invalid version",
+			errorMessage: "v1.1.0 is an invalid pub package version. It must be a semantic version like 1.2.3.",
+			pkgs: map[string][]string{
+				"http": {"v1.1.0"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(config.Import{Type: "pub"}, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	require.Equal(t, map[string]string{}, new(Registry).AdditionalEnvVars("name", "version"))
+}
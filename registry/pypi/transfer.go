@@ -0,0 +1,454 @@
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/khulnasoft/packages-registry/util"
+	"golang.org/x/exp/slices"
+)
+
+// Transfer copies all the files of a PyPI package version without pip and twine. The files are
+// listed in the source simple index, verified with the sha256 digest of their link and uploaded
+// with the upload API used by twine. See:
+// - https://peps.python.org/pep-0503/
+// - https://warehouse.pypa.io/api-reference/legacy.html#upload-api
+func (r *Registry) Transfer(ctx context.Context, client *transfer.Client, name, version string) error {
+	source := r.pkgsImport.Source
+	sourceAuth := transfer.CredentialsAuth(source.Credentials)
+
+	projectUrl := fmt.Sprintf("%s/%s/", strings.TrimSuffix(source.URL, "/"), normalize(name))
+	index, err := client.Get(ctx, projectUrl, sourceAuth, "Accept", "text/html")
+	if err != nil {
+		return err
+	}
+
+	files, err := r.distributionFiles(projectUrl, string(index), name, version)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("version %s of package %s not found in %s", version, name, source.URL)
+	}
+
+	for _, file := range files {
+		auth := sourceAuth
+		if !transfer.SameHost(file.url, source.URL) {
+			auth = nil
+		}
+
+		if err := r.copyFile(ctx, client, file, auth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) copyFile(ctx context.Context, client *transfer.Client, file distributionFile, auth transfer.Auth) error {
+	content, err := client.Download(ctx, file.url, auth)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if len(file.sha256) != 0 {
+		if err := content.VerifyChecksum("sha256", file.sha256, file.name); err != nil {
+			return err
+		}
+	}
+
+	return r.upload(ctx, client, file, content)
+}
+
+type distributionFile struct {
+	name, url, sha256, fileType, pyVersion string
+}
+
+var (
+	anchorRegexp        = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*"([^"]*)"[^>]*>(.*?)</a>`)
+	nameSeparatorRegexp = regexp.MustCompile(`[-_.]+`)
+	sdistExtensions     = []string{".tar.gz", ".zip"}
+)
+
+// normalize returns the normalized name of a project, see
+// https://packaging.python.org/en/latest/specifications/name-normalization/.
+func normalize(name string) string {
+	return strings.ToLower(nameSeparatorRegexp.ReplaceAllString(name, "-"))
+}
+
+// NormalizeName returns the normalized name of a project, like my-project for My_Project.
+func (r *Registry) NormalizeName(name string) string {
+	return normalize(name)
+}
+
+// distributionFiles returns the wheels and source distributions of the version listed in the
+// simple index of the project.
+func (r *Registry) distributionFiles(projectUrl, index, name, version string) ([]distributionFile, error) {
+	base, err := url.Parse(projectUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []distributionFile{}
+	for _, match := range anchorRegexp.FindAllStringSubmatch(index, -1) {
+		link, err := base.Parse(html.UnescapeString(match[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		file := distributionFile{name: path.Base(link.Path)}
+		fileName, fileVersion, ok := file.parseName()
+		if !ok || normalize(fileName) != normalize(name) || fileVersion != version {
+			continue
+		}
+
+		if strings.HasPrefix(link.Fragment, "sha256=") {
+			file.sha256 = strings.TrimPrefix(link.Fragment, "sha256=")
+		}
+		link.Fragment = ""
+		file.url = link.String()
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Versions lists the versions of the files in the source simple index of the project.
+func (r *Registry) Versions(ctx context.Context, client *transfer.Client, name string) ([]string, error) {
+	source := r.pkgsImport.Source
+
+	projectUrl := fmt.Sprintf("%s/%s/", strings.TrimSuffix(source.URL, "/"), normalize(name))
+	index, err := client.Get(ctx, projectUrl, transfer.CredentialsAuth(source.Credentials), "Accept", "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{}
+	for _, match := range anchorRegexp.FindAllStringSubmatch(string(index), -1) {
+		link, err := url.Parse(html.UnescapeString(match[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		file := distributionFile{name: path.Base(link.Path)}
+		fileName, fileVersion, ok := file.parseName()
+		if ok && normalize(fileName) == normalize(name) && !slices.Contains(versions, fileVersion) {
+			versions = append(versions, fileVersion)
+		}
+	}
+
+	return versions, nil
+}
+
+// parseName reads the project name and the version of a wheel or a source distribution file name
+// and sets the file type and the python version of the upload.
+func (f *distributionFile) parseName() (string, string, bool) {
+	if base := strings.TrimSuffix(f.name, ".whl"); base != f.name {
+		// {distribution}-{version}(-{build tag})?-{python tag}-{abi tag}-{platform tag}.whl
+		parts := strings.Split(base, "-")
+		if len(parts) < 5 {
+			return "", "", false
+		}
+		f.fileType, f.pyVersion = "bdist_wheel", parts[len(parts)-3]
+		return parts[0], parts[1], true
+	}
+
+	for _, ext := range sdistExtensions {
+		if base := strings.TrimSuffix(f.name, ext); base != f.name {
+			index := strings.LastIndex(base, "-")
+			if index == -1 {
+				return "", "", false
+			}
+			f.fileType, f.pyVersion = "sdist", "source"
+			return base[:index], base[index+1:], true
+		}
+	}
+
+	return "", "", false
+}
+
+// Metadata fields with a different name in the upload form, see
+// https://packaging.python.org/en/latest/specifications/core-metadata/.
+var formFieldNames = map[string]string{
+	"classifier":  "classifiers",
+	"project_url": "project_urls",
+}
+
+func (r *Registry) upload(ctx context.Context, client *transfer.Client, file distributionFile, content *transfer.File) error {
+	metadata, err := readMetadata(file, content)
+	if err != nil {
+		return err
+	}
+
+	sha256Digest, err := content.Checksum("sha256")
+	if err != nil {
+		return err
+	}
+	md5Digest, err := content.Checksum("md5")
+	if err != nil {
+		return err
+	}
+	fields := []transfer.FormField{
+		{Name: ":action", Value: "file_upload"},
+		{Name: "protocol_version", Value: "1"},
+		{Name: "filetype", Value: file.fileType},
+		{Name: "pyversion", Value: file.pyVersion},
+		{Name: "sha256_digest", Value: sha256Digest},
+		{Name: "md5_digest", Value: md5Digest},
+	}
+
+	for _, key := range util.OrderedMapKeysOf(metadata.Header) {
+		fieldName := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		if renamed, ok := formFieldNames[fieldName]; ok {
+			fieldName = renamed
+		}
+		for _, value := range metadata.Header[key] {
+			fields = append(fields, transfer.FormField{Name: fieldName, Value: value})
+		}
+	}
+
+	description, err := io.ReadAll(metadata.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(description)) != 0 {
+		fields = append(fields, transfer.FormField{Name: "description", Value: string(description)})
+	}
+
+	body, size, contentType, err := transfer.MultipartForm(fields, "content", file.name, content)
+	if err != nil {
+		return err
+	}
+
+	destination := r.pkgsImport.Destination
+	return client.Upload(ctx, http.MethodPost, destination.URL, transfer.CredentialsAuth(destination.Credentials), body, size, "Content-Type", contentType)
+}
+
+// readMetadata reads the core metadata of a distribution file: the METADATA file of the
+// .dist-info directory of a wheel or the PKG-INFO file of a source distribution.
+func readMetadata(file distributionFile, content *transfer.File) (*mail.Message, error) {
+	var metadata []byte
+	var err error
+
+	switch {
+	case strings.HasSuffix(file.name, ".whl"):
+		metadata, err = readZipMetadata(content.Reader(), content.Size(), "METADATA")
+	case strings.HasSuffix(file.name, ".zip"):
+		metadata, err = readZipMetadata(content.Reader(), content.Size(), "PKG-INFO")
+	case strings.HasSuffix(file.name, ".tar.gz"):
+		metadata, err = readTarGzMetadata(content.Reader(), "PKG-INFO")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata == nil {
+		return nil, fmt.Errorf("no metadata found in %s", file.name)
+	}
+
+	return mail.ReadMessage(bytes.NewReader(metadata))
+}
+
+// isMetadataFile returns true for the metadata file of a top level directory. The METADATA file
+// of a wheel is in the .dist-info directory.
+func isMetadataFile(filePath, metadataFileName string) bool {
+	dir, fileName := path.Split(filePath)
+	if fileName != metadataFileName || strings.Count(dir, "/") != 1 {
+		return false
+	}
+
+	return metadataFileName != "METADATA" || strings.HasSuffix(dir, ".dist-info/")
+}
+
+func readZipMetadata(content io.ReaderAt, size int64, metadataFileName string) ([]byte, error) {
+	archive, err := zip.NewReader(content, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range archive.File {
+		if !isMetadataFile(f.Name, metadataFileName) {
+			continue
+		}
+
+		reader, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+
+	return nil, nil
+}
+
+func readTarGzMetadata(content io.Reader, metadataFileName string) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(content)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewReader(gzipReader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if isMetadataFile(header.Name, metadataFileName) {
+			return io.ReadAll(archive)
+		}
+	}
+}
+
+// Exists looks up the files of the version in the destination simple index. The index is expected at
+// <destination url>/simple, like in KhulnaSoft and Artifactory.
+func (r *Registry) Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) {
+	destination := r.pkgsImport.Destination
+
+	projectUrl := fmt.Sprintf("%s/simple/%s/", strings.TrimSuffix(destination.URL, "/"), normalize(name))
+	index, err := client.Get(ctx, projectUrl, transfer.CredentialsAuth(destination.Credentials), "Accept", "text/html")
+	if transfer.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	files, err := r.distributionFiles(projectUrl, string(index), name, version)
+	return len(files) != 0, err
+}
+
+// Packages lists the projects of the source simple index. The prefix is not used: the index lists
+// all the projects.
+func (r *Registry) Packages(ctx context.Context, client *transfer.Client, prefix string) ([]string, error) {
+	source := r.pkgsImport.Source
+
+	index, err := client.Get(ctx, strings.TrimSuffix(source.URL, "/")+"/", transfer.CredentialsAuth(source.Credentials), "Accept", "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, match := range anchorRegexp.FindAllStringSubmatch(string(index), -1) {
+		names = append(names, strings.TrimSpace(html.UnescapeString(match[2])))
+	}
+
+	return names, nil
+}
+
+// requirementRegexp reads a Requires-Dist value: a name, optional extras, optional version
+// specifiers with or without parentheses and an optional environment marker. See
+// https://packaging.python.org/en/latest/specifications/dependency-specifiers/.
+var requirementRegexp = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*\(?([^;()]*)\)?\s*(?:;(.*))?$`)
+
+// Dependencies reads the Requires-Dist fields of the core metadata of a wheel of the version, or of
+// its source distribution without wheels. The dependencies of extras are not included.
+func (r *Registry) Dependencies(ctx context.Context, client *transfer.Client, name, packageVersion string) ([]version.Dependency, error) {
+	source := r.pkgsImport.Source
+	sourceAuth := transfer.CredentialsAuth(source.Credentials)
+
+	projectUrl := fmt.Sprintf("%s/%s/", strings.TrimSuffix(source.URL, "/"), normalize(name))
+	index, err := client.Get(ctx, projectUrl, sourceAuth, "Accept", "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := r.distributionFiles(projectUrl, string(index), name, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("version %s of package %s not found in %s", packageVersion, name, source.URL)
+	}
+
+	file := files[0]
+	for _, f := range files {
+		if f.fileType == "bdist_wheel" {
+			file = f
+			break
+		}
+	}
+
+	if !transfer.SameHost(file.url, source.URL) {
+		sourceAuth = nil
+	}
+	content, err := client.Download(ctx, file.url, sourceAuth)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	metadata, err := readMetadata(file, content)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := []version.Dependency{}
+	for _, requirement := range metadata.Header["Requires-Dist"] {
+		match := requirementRegexp.FindStringSubmatch(requirement)
+		if match == nil {
+			return nil, fmt.Errorf("invalid Requires-Dist %q in %s", requirement, file.name)
+		}
+		if strings.Contains(match[3], "extra") {
+			continue
+		}
+
+		dependencies = append(dependencies, version.Dependency{Name: normalize(match[1]), Constraint: specifiersConstraint(match[2])})
+	}
+
+	return dependencies, nil
+}
+
+// specifiersConstraint converts PEP 440 version specifiers, like >=2.0,<3 or ~=1.4.2, into a
+// constraint. See https://peps.python.org/pep-0440/#version-specifiers.
+func specifiersConstraint(specifiers string) string {
+	constraints := []string{}
+
+	for _, specifier := range strings.Split(specifiers, ",") {
+		specifier = strings.ReplaceAll(specifier, " ", "")
+
+		switch {
+		case len(specifier) == 0:
+		case strings.HasPrefix(specifier, "~="):
+			// ~=1.4.2 is >=1.4.2, ==1.4.*
+			v := strings.TrimPrefix(specifier, "~=")
+			constraints = append(constraints, ">="+v)
+			if segments := strings.Split(v, "."); len(segments) > 1 {
+				constraints = append(constraints, "="+strings.Join(segments[:len(segments)-1], ".")+".*")
+			}
+		case strings.HasPrefix(specifier, "==="):
+			constraints = append(constraints, "="+strings.TrimPrefix(specifier, "==="))
+		case strings.HasPrefix(specifier, "=="):
+			constraints = append(constraints, "="+strings.TrimPrefix(specifier, "=="))
+		default:
+			constraints = append(constraints, specifier)
+		}
+	}
+
+	if len(constraints) == 0 {
+		return version.All
+	}
+
+	return strings.Join(constraints, ", ")
+}
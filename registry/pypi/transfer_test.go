@@ -0,0 +1,276 @@
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/stretchr/testify/require"
+)
+
+const metadata = `Metadata-Version: 2.1
+Name: My.Package
+Version: 1.2.3
+Summary: A package
+Classifier: Programming Language :: Python
+Classifier: License :: OSI Approved :: MIT License
+
+The description.
+`
+
+func TestTransfer(t *testing.T) {
+	wheel := zipArchive(t, "my_package-1.2.3.dist-info/METADATA", metadata)
+	sdist := tarGzArchive(t, "My.Package-1.2.3/PKG-INFO", metadata)
+
+	tests := []struct {
+		name            string
+		version         string
+		wheelDigest     string
+		expectedUploads []string
+		expectedError   string
+	}{
+		{
+			name:            "wheel and sdist",
+			version:         "1.2.3",
+			wheelDigest:     fmt.Sprintf("%x", sha256.Sum256(wheel)),
+			expectedUploads: []string{"my_package-1.2.3-py3-none-any.whl bdist_wheel py3", "My.Package-1.2.3.tar.gz sdist source"},
+		},
+		{
+			name:          "unknown version",
+			version:       "4.5.6",
+			expectedError: "version 4.5.6 of package My.Package not found in <source>",
+		},
+		{
+			name:          "checksum mismatch",
+			version:       "1.2.3",
+			wheelDigest:   "0000",
+			expectedError: fmt.Sprintf("sha256 checksum mismatch for my_package-1.2.3-py3-none-any.whl: expected 0000, got %x", sha256.Sum256(wheel)),
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				username, password, _ := r.BasicAuth()
+				require.Equal(t, "user:SOURCE_TOKEN", username+":"+password)
+
+				switch r.URL.Path {
+				case "/simple/my-package/":
+					fmt.Fprintf(w, `<html><body>
+<a href="../../files/my_package-1.2.3-py3-none-any.whl#sha256=%s">my_package-1.2.3-py3-none-any.whl</a>
+<a href="../../files/My.Package-1.2.3.tar.gz" data-requires-python="&gt;=3.8">My.Package-1.2.3.tar.gz</a>
+<a href="../../files/my_package-1.0.0-py3-none-any.whl">my_package-1.0.0-py3-none-any.whl</a>
+</body></html>`, spec.wheelDigest)
+				case "/files/my_package-1.2.3-py3-none-any.whl":
+					w.Write(wheel)
+				case "/files/My.Package-1.2.3.tar.gz":
+					w.Write(sdist)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(source.Close)
+
+			uploads := []string{}
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				username, password, _ := r.BasicAuth()
+				require.Equal(t, "user:DESTINATION_TOKEN", username+":"+password)
+
+				_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				require.NoError(t, err)
+				form, err := multipart.NewReader(r.Body, params["boundary"]).ReadForm(1 << 20)
+				require.NoError(t, err)
+
+				require.Equal(t, []string{"file_upload"}, form.Value[":action"])
+				require.Equal(t, []string{"My.Package"}, form.Value["name"])
+				require.Equal(t, []string{"1.2.3"}, form.Value["version"])
+				require.Equal(t, []string{"2.1"}, form.Value["metadata_version"])
+				require.Equal(t, []string{"Programming Language :: Python", "License :: OSI Approved :: MIT License"}, form.Value["classifiers"])
+				require.Equal(t, []string{"The description.\n"}, form.Value["description"])
+
+				file := form.File["content"][0]
+				uploads = append(uploads, fmt.Sprintf("%s %s %s", file.Filename, form.Value["filetype"][0], form.Value["pyversion"][0]))
+			}))
+			t.Cleanup(destination.Close)
+
+			registry := Registry{
+				pkgsImport: config.Import{
+					Source: config.Registry{
+						URL: source.URL + "/simple/",
+						Credentials: config.Credentials{
+							Token:                "SOURCE_TOKEN",
+							AdditionalParameters: map[string]string{"username": "user"},
+						},
+					},
+					Destination: config.Registry{
+						URL: destination.URL + "/legacy/",
+						Credentials: config.Credentials{
+							Token:                "DESTINATION_TOKEN",
+							AdditionalParameters: map[string]string{"username": "user"},
+						},
+					},
+				},
+			}
+
+			err := registry.Transfer(context.Background(), transfer.NewClient(nil), "My.Package", spec.version)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, strings.Replace(spec.expectedError, "<source>", source.URL+"/simple/", 1))
+				require.Empty(t, uploads)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expectedUploads, uploads)
+		})
+	}
+}
+
+func zipArchive(t *testing.T, name, content string) []byte {
+	buff := new(bytes.Buffer)
+	writer := zip.NewWriter(buff)
+
+	file, err := writer.Create(name)
+	require.NoError(t, err)
+	_, err = file.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buff.Bytes()
+}
+
+func tarGzArchive(t *testing.T, name, content string) []byte {
+	buff := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buff)
+	writer := tar.NewWriter(gzipWriter)
+
+	require.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+	_, err := writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	return buff.Bytes()
+}
+
+func TestVersions(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/simple/my-package/", r.URL.Path)
+		w.Write([]byte(`<html><body>
+<a href="../../files/My.Package-1.2.3.tar.gz#sha256=1234">My.Package-1.2.3.tar.gz</a>
+<a href="../../files/my_package-1.2.3-py3-none-any.whl">my_package-1.2.3-py3-none-any.whl</a>
+<a href="../../files/my_package-2.0.0-py3-none-any.whl">my_package-2.0.0-py3-none-any.whl</a>
+<a href="../../files/other_package-3.0.0-py3-none-any.whl">other_package-3.0.0-py3-none-any.whl</a>
+</body></html>`))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/simple"},
+		},
+	}
+
+	versions, err := registry.Versions(context.Background(), transfer.NewClient(nil), "My.Package")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.2.3", "2.0.0"}, versions)
+}
+
+func TestPackages(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/simple/", r.URL.Path)
+		w.Write([]byte(`<html><body>
+<a href="/simple/acme-first/">acme-first</a>
+<a href="/simple/acme-second/">Acme_Second</a>
+<a href="/simple/other/">other</a>
+</body></html>`))
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/simple"},
+		},
+	}
+
+	names, err := registry.Packages(context.Background(), transfer.NewClient(nil), "acme-")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme-first", "Acme_Second", "other"}, names)
+}
+
+func TestDependencies(t *testing.T) {
+	wheel := zipArchive(t, "my_package-1.2.3.dist-info/METADATA", `Metadata-Version: 2.1
+Name: My.Package
+Version: 1.2.3
+Requires-Dist: Requests (>=2.0,<3)
+Requires-Dist: six
+Requires-Dist: typing_extensions~=4.1 ; python_version < "3.8"
+Requires-Dist: pytest==7.* ; extra == "test"
+`)
+
+	var source *httptest.Server
+	source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simple/my-package/":
+			fmt.Fprintf(w, `<a href="%s/files/My.Package-1.2.3.tar.gz">sdist</a><a href="%s/files/my_package-1.2.3-py3-none-any.whl">wheel</a>`, source.URL, source.URL)
+		case "/files/my_package-1.2.3-py3-none-any.whl":
+			w.Write(wheel)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(source.Close)
+
+	registry := Registry{
+		pkgsImport: config.Import{
+			Source: config.Registry{URL: source.URL + "/simple"},
+		},
+	}
+
+	dependencies, err := registry.Dependencies(context.Background(), transfer.NewClient(nil), "My.Package", "1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, []version.Dependency{
+		{Name: "requests", Constraint: ">=2.0, <3"},
+		{Name: "six", Constraint: "*"},
+		{Name: "typing-extensions", Constraint: ">=4.1, =4.*"},
+	}, dependencies)
+
+	_, err = registry.Dependencies(context.Background(), transfer.NewClient(nil), "My.Package", "2.0.0")
+	require.EqualError(t, err, "version 2.0.0 of package My.Package not found in "+source.URL+"/simple")
+}
+
+func TestSpecifiersConstraint(t *testing.T) {
+	tests := []struct {
+		specifiers string
+		expected   string
+	}{
+		{specifiers: "", expected: "*"},
+		{specifiers: ">=2.0, <3", expected: ">=2.0, <3"},
+		{specifiers: "~=1.4.2", expected: ">=1.4.2, =1.4.*"},
+		{specifiers: "==1.2.*", expected: "=1.2.*"},
+		{specifiers: "===1.0", expected: "=1.0"},
+		{specifiers: "!=1.5,>1", expected: "!=1.5, >1"},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.specifiers, func(t *testing.T) {
+			require.Equal(t, spec.expected, specifiersConstraint(spec.specifiers))
+		})
+	}
+}
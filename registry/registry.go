@@ -3,13 +3,35 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/cargo"
+	"github.com/khulnasoft/packages-registry/registry/composer"
+	"github.com/khulnasoft/packages-registry/registry/conan"
+	"github.com/khulnasoft/packages-registry/registry/conda"
+	"github.com/khulnasoft/packages-registry/registry/debian"
+	"github.com/khulnasoft/packages-registry/registry/generic"
+	"github.com/khulnasoft/packages-registry/registry/golang"
+	"github.com/khulnasoft/packages-registry/registry/helm"
+	"github.com/khulnasoft/packages-registry/registry/hex"
 	"github.com/khulnasoft/packages-registry/registry/maven"
 	"github.com/khulnasoft/packages-registry/registry/npm"
 	"github.com/khulnasoft/packages-registry/registry/nuget"
+	"github.com/khulnasoft/packages-registry/registry/oci"
+	"github.com/khulnasoft/packages-registry/registry/pub"
 	"github.com/khulnasoft/packages-registry/registry/pypi"
+	"github.com/khulnasoft/packages-registry/registry/rpm"
+	"github.com/khulnasoft/packages-registry/registry/rubygems"
+	"github.com/khulnasoft/packages-registry/registry/terraform"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
+	"github.com/khulnasoft/packages-registry/registry/version"
+	"github.com/khulnasoft/packages-registry/util"
+	"golang.org/x/exp/slices"
 )
 
 // Registry is the interface that all package format dedicated registries will comply to.
@@ -19,9 +41,312 @@ type Registry interface {
 	AdditionalEnvVars(string, string) map[string]string // Returns the additional environment variables that the pipeline jobs might need.
 }
 
+// Transferer is the interface of the registries that can also import a package directly from this
+// process, without a pipeline job, its image and its scripts.
+type Transferer interface {
+	Transfer(ctx context.Context, client *transfer.Client, name, version string) error // Copies a single package version from the source to the destination.
+}
+
+// TransferLocker is the interface of the Transferers whose transfers of some package versions can't
+// run at the same time, like the Maven ones updating the maven-metadata.xml file of their artifact.
+type TransferLocker interface {
+	TransferLock(name, version string) string // Returns the key shared by the transfers to run one at a time, empty for none.
+}
+
+// ExistenceChecker is the interface of the registries that can tell whether a package version is
+// already in the destination registry. It's needed by the on_existing skip and fail values.
+type ExistenceChecker interface {
+	Exists(ctx context.Context, client *transfer.Client, name, version string) (bool, error) // Returns true when the package version is in the destination.
+}
+
+// VersionLister is the interface of the registries that can list the versions of a package in the
+// source registry. It's needed by the version specs, like ranges and latest:N.
+type VersionLister interface {
+	Versions(ctx context.Context, client *transfer.Client, name string) ([]string, error) // Returns the versions of the package in the source.
+}
+
+// PackageLister is the interface of the registries that can list the packages of the source
+// registry. It's needed by the package name patterns, like "@acme/*".
+type PackageLister interface {
+	Packages(ctx context.Context, client *transfer.Client, prefix string) ([]string, error) // Returns the names of the source packages starting with the prefix. Other names can be returned.
+}
+
+// DependencyLister is the interface of the registries that can read the dependencies of a package
+// version in the source registry. It's needed by include_dependencies.
+type DependencyLister interface {
+	Dependencies(ctx context.Context, client *transfer.Client, name, version string) ([]version.Dependency, error) // Returns the dependencies of the package version in the source.
+}
+
+// NameNormalizer is the interface of the registries whose package names have several spellings, like
+// the case insensitive NuGet ids. It's needed to find the dependencies already in the packages.
+type NameNormalizer interface {
+	NormalizeName(name string) string // Returns the name shared by all the spellings of the package name.
+}
+
+// ErrAlreadyExists is returned for the packages already in the destination of an import with
+// on_existing: fail.
+var ErrAlreadyExists = errors.New("already exists in the destination")
+
 // GetRegistry will read the given import type and return the correct registry for the right package
-// format. Returns an error if such registry can't be found.
+// format. Returns an error if such registry can't be found or if it can't handle the on_existing or
+// include_dependencies values.
 func GetRegistry(pkgsImport config.Import, importName string) (Registry, error) {
+	registry, err := newRegistry(pkgsImport, importName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := registry.(ExistenceChecker); pkgsImport.ChecksExisting() && !ok {
+		return nil, fmt.Errorf("the %q type of import %q doesn't support on_existing: %s yet", pkgsImport.Type, importName, pkgsImport.OnExisting)
+	}
+
+	if _, ok := registry.(DependencyLister); pkgsImport.IncludeDependencies && !ok {
+		return nil, fmt.Errorf("the %q type of import %q doesn't support include_dependencies yet", pkgsImport.Type, importName)
+	}
+
+	return registry, nil
+}
+
+// ShouldImport looks up the package version in the destination when the import requires it. It
+// returns false for a package to skip and an ErrAlreadyExists error for a package that must fail.
+func ShouldImport(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName, name, version string) (bool, error) {
+	checker, ok := registry.(ExistenceChecker)
+	if !pkgsImport.ChecksExisting() || !ok {
+		return true, nil
+	}
+
+	exists, err := checker.Exists(ctx, client, name, version)
+	if err != nil || !exists {
+		return err == nil, err
+	}
+
+	if pkgsImport.OnExisting == config.OnExistingFail {
+		return false, fmt.Errorf("%s %s of import %q %w", name, version, importName, ErrAlreadyExists)
+	}
+
+	return false, nil
+}
+
+// ResolvePackages returns the package versions to import: the package name patterns and the version
+// specs are resolved against the source registry and, with include_dependencies, the dependencies
+// are added.
+func ResolvePackages(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName string, packages map[string][]string) (map[string][]string, error) {
+	packages, err := ExpandPackages(ctx, client, registry, pkgsImport, importName, packages)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range util.OrderedMapKeysOf(packages) {
+		if packages[name], err = ResolveVersions(ctx, client, registry, pkgsImport, importName, name, packages[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if !pkgsImport.IncludeDependencies {
+		return packages, nil
+	}
+
+	return resolveDependencies(ctx, client, registry, pkgsImport, importName, packages)
+}
+
+// resolveDependencies adds the dependencies of the packages, and their own dependencies, to the
+// packages. Each package version is only read once, so cycles are fine. A dependency spelled
+// differently than a package, like newtonsoft.json for Newtonsoft.Json in NuGet, is added to it.
+func resolveDependencies(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName string, packages map[string][]string) (map[string][]string, error) {
+	lister := registry.(DependencyLister)
+	published := map[string][]string{}
+
+	normalize := func(name string) string { return name }
+	if normalizer, ok := registry.(NameNormalizer); ok {
+		normalize = normalizer.NormalizeName
+	}
+	names := map[string]string{}
+
+	type packageVersion struct{ name, version string }
+	queue := []packageVersion{}
+	for _, name := range util.OrderedMapKeysOf(packages) {
+		if _, ok := names[normalize(name)]; !ok {
+			names[normalize(name)] = name
+		}
+		for _, v := range packages[name] {
+			queue = append(queue, packageVersion{name, v})
+		}
+	}
+
+	for len(queue) != 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		dependencies, err := lister.Dependencies(ctx, client, current.name, current.version)
+		if err != nil {
+			return nil, fmt.Errorf("can't read the dependencies of %s %s in import %q: %w", current.name, current.version, importName, err)
+		}
+
+		for _, dependency := range dependencies {
+			if name, ok := names[normalize(dependency.Name)]; ok {
+				dependency.Name = name
+			} else {
+				names[normalize(dependency.Name)] = dependency.Name
+			}
+
+			v := dependency.Version
+			if len(v) == 0 {
+				if v, err = resolveConstraint(ctx, client, registry, pkgsImport, importName, dependency, published); err != nil {
+					return nil, fmt.Errorf("%w, required by %s %s", err, current.name, current.version)
+				}
+			}
+
+			if !slices.Contains(packages[dependency.Name], v) {
+				packages[dependency.Name] = append(packages[dependency.Name], v)
+				queue = append(queue, packageVersion{dependency.Name, v})
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+// resolveConstraint returns the most recent source version matching the constraint of a dependency.
+// The versions of each package are only listed once.
+func resolveConstraint(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName string, dependency version.Dependency, published map[string][]string) (string, error) {
+	versions, ok := published[dependency.Name]
+	if !ok {
+		lister, ok := registry.(VersionLister)
+		if !ok {
+			return "", fmt.Errorf("the %q type of import %q doesn't support version constraints like %q yet", pkgsImport.Type, importName, dependency.Constraint)
+		}
+
+		var err error
+		if versions, err = lister.Versions(ctx, client, dependency.Name); err != nil {
+			return "", fmt.Errorf("can't list the versions of %s in import %q: %w", dependency.Name, importName, err)
+		}
+		published[dependency.Name] = versions
+	}
+
+	v, err := version.Latest(versionScheme(pkgsImport), dependency.Constraint, versions)
+	if err != nil {
+		return "", fmt.Errorf("package %s of import %q: %w", dependency.Name, importName, err)
+	}
+	if len(v) == 0 {
+		return "", fmt.Errorf("no version of %s in import %q matches %q", dependency.Name, importName, dependency.Constraint)
+	}
+
+	return v, nil
+}
+
+// versionScheme returns the versioning scheme of the packages of the import. Maven versions, like
+// 31.1-jre or 6.2.7.Final, aren't semantic versions.
+func versionScheme(pkgsImport config.Import) version.Scheme {
+	if pkgsImport.Type == "maven" {
+		return version.Maven
+	}
+
+	return version.Semantic
+}
+
+// ResolveVersions replaces the version specs of a package, like ranges and latest:N, with the
+// matching versions of the source registry. The literal versions are kept and the duplicates are
+// removed. The source registry is only queried when there is a spec.
+func ResolveVersions(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName, name string, versions []string) ([]string, error) {
+	var published []string
+	resolved := []string{}
+
+	for _, v := range versions {
+		if !version.IsSpec(v) {
+			resolved = appendNew(resolved, v)
+			continue
+		}
+
+		if published == nil {
+			lister, ok := registry.(VersionLister)
+			if !ok {
+				return nil, fmt.Errorf("the %q type of import %q doesn't support version specs like %q yet", pkgsImport.Type, importName, v)
+			}
+
+			var err error
+			if published, err = lister.Versions(ctx, client, name); err != nil {
+				return nil, fmt.Errorf("can't list the versions of %s in import %q: %w", name, importName, err)
+			}
+		}
+
+		matching, err := version.Resolve(versionScheme(pkgsImport), v, published)
+		if err != nil {
+			return nil, fmt.Errorf("package %s of import %q: %w", name, importName, err)
+		}
+		if len(matching) == 0 {
+			return nil, fmt.Errorf("no version of %s in import %q matches %q", name, importName, v)
+		}
+
+		resolved = appendNew(resolved, matching...)
+	}
+
+	return resolved, nil
+}
+
+// ExpandPackages replaces the package name patterns, like "@acme/*", with the matching packages of
+// the source registry. A * matches any sequence of characters and the names are compared without
+// case. The matching packages get the versions of the pattern, in addition to the versions they
+// already have.
+func ExpandPackages(ctx context.Context, client *transfer.Client, registry Registry, pkgsImport config.Import, importName string, packages map[string][]string) (map[string][]string, error) {
+	expanded := make(map[string][]string, len(packages))
+
+	for _, name := range util.OrderedMapKeysOf(packages) {
+		versions := packages[name]
+		if !strings.Contains(name, namePatternWildcard) {
+			expanded[name] = appendNew(expanded[name], versions...)
+			continue
+		}
+
+		lister, ok := registry.(PackageLister)
+		if !ok {
+			return nil, fmt.Errorf("the %q type of import %q doesn't support package name patterns like %q yet", pkgsImport.Type, importName, name)
+		}
+
+		prefix, _, _ := strings.Cut(name, namePatternWildcard)
+		candidates, err := lister.Packages(ctx, client, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("can't list the packages matching %q in import %q: %w", name, importName, err)
+		}
+
+		pattern := namePatternRegexp(name)
+		matched := false
+		for _, candidate := range candidates {
+			if pattern.MatchString(candidate) {
+				expanded[candidate] = appendNew(expanded[candidate], versions...)
+				matched = true
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("no package of import %q matches %q", importName, name)
+		}
+	}
+
+	return expanded, nil
+}
+
+const namePatternWildcard = "*"
+
+func namePatternRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, namePatternWildcard)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+func appendNew(versions []string, newVersions ...string) []string {
+	for _, v := range newVersions {
+		if !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+
+	return versions
+}
+
+func newRegistry(pkgsImport config.Import, importName string) (Registry, error) {
 	switch pkgsImport.Type {
 	case "npm":
 		return npm.NewRegistry(pkgsImport)
@@ -31,6 +356,34 @@ func GetRegistry(pkgsImport config.Import, importName string) (Registry, error)
 		return maven.NewRegistry(pkgsImport, importName)
 	case "pypi":
 		return pypi.NewRegistry(pkgsImport)
+	case "golang":
+		return golang.NewRegistry(pkgsImport, importName)
+	case "rubygems":
+		return rubygems.NewRegistry(pkgsImport)
+	case "composer":
+		return composer.NewRegistry(pkgsImport, importName)
+	case "helm":
+		return helm.NewRegistry(pkgsImport)
+	case "cargo":
+		return cargo.NewRegistry(pkgsImport, importName)
+	case "generic":
+		return generic.NewRegistry(pkgsImport, importName)
+	case "conan":
+		return conan.NewRegistry(pkgsImport, importName)
+	case "docker", "oci":
+		return oci.NewRegistry(pkgsImport, importName)
+	case "terraform":
+		return terraform.NewRegistry(pkgsImport, importName)
+	case "debian":
+		return debian.NewRegistry(pkgsImport, importName)
+	case "rpm":
+		return rpm.NewRegistry(pkgsImport, importName)
+	case "conda":
+		return conda.NewRegistry(pkgsImport, importName)
+	case "pub":
+		return pub.NewRegistry(pkgsImport, importName)
+	case "hex":
+		return hex.NewRegistry(pkgsImport, importName)
 	}
 
 	return nil, fmt.Errorf("no registry object for type %q in import %q", pkgsImport.Type, importName)
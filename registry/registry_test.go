@@ -1,9 +1,16 @@
 package registry
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/khulnasoft/packages-registry/config"
+	"github.com/khulnasoft/packages-registry/registry/transfer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -11,6 +18,7 @@ func TestGetRegistry(t *testing.T) {
 	tests := []struct {
 		name           string
 		importType     string
+		sourceUrl      string
 		expectRegistry bool
 		expectError    bool
 	}{
@@ -38,6 +46,98 @@ func TestGetRegistry(t *testing.T) {
 			expectRegistry: true,
 			expectError:    false,
 		},
+		{
+			name:           "with type golang",
+			importType:     "golang",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type rubygems",
+			importType:     "rubygems",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type composer",
+			importType:     "composer",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type helm",
+			importType:     "helm",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type cargo",
+			importType:     "cargo",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type generic",
+			importType:     "generic",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type conan",
+			importType:     "conan",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type docker",
+			importType:     "docker",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type oci",
+			importType:     "oci",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type terraform",
+			importType:     "terraform",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type debian",
+			importType:     "debian",
+			sourceUrl:      "https://deb.debian.org/debian/dists/bookworm/main",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type rpm",
+			importType:     "rpm",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type conda",
+			importType:     "conda",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type pub",
+			importType:     "pub",
+			expectRegistry: true,
+			expectError:    false,
+		},
+		{
+			name:           "with type hex",
+			importType:     "hex",
+			sourceUrl:      "https://repo.hex.pm",
+			expectRegistry: true,
+			expectError:    false,
+		},
 		{
 			name:           "with an unknown type",
 			importType:     "you_dont_know_me",
@@ -48,7 +148,7 @@ func TestGetRegistry(t *testing.T) {
 
 	for _, spec := range tests {
 		t.Run(spec.name, func(t *testing.T) {
-			pkgsImport := config.Import{Type: spec.importType}
+			pkgsImport := config.Import{Type: spec.importType, Source: config.Registry{URL: spec.sourceUrl}}
 
 			registry, err := GetRegistry(pkgsImport, "import1")
 
@@ -66,3 +166,373 @@ func TestGetRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRegistryOptions(t *testing.T) {
+	tests := []struct {
+		name                string
+		importType          string
+		onExisting          string
+		includeDependencies bool
+		expectedError       string
+	}{
+		{
+			name:       "with overwrite",
+			importType: "helm",
+			onExisting: config.OnExistingOverwrite,
+		},
+		{
+			name:       "with skip on a supported type",
+			importType: "npm",
+			onExisting: config.OnExistingSkip,
+		},
+		{
+			name:                "with include_dependencies on a supported type",
+			importType:          "maven",
+			includeDependencies: true,
+		},
+		{
+			name:                "with include_dependencies on an unsupported type",
+			importType:          "helm",
+			includeDependencies: true,
+			expectedError:       `the "helm" type of import "import1" doesn't support include_dependencies yet`,
+		},
+		{
+			name:          "with skip on an unsupported type",
+			importType:    "helm",
+			onExisting:    config.OnExistingSkip,
+			expectedError: `the "helm" type of import "import1" doesn't support on_existing: skip yet`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			pkgsImport := config.Import{Type: spec.importType, OnExisting: spec.onExisting, IncludeDependencies: spec.includeDependencies}
+
+			_, err := GetRegistry(pkgsImport, "import1")
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShouldImport(t *testing.T) {
+	tests := []struct {
+		name           string
+		onExisting     string
+		packageName    string
+		expectedImport bool
+		expectedError  string
+	}{
+		{
+			name:           "without on_existing",
+			packageName:    "existing",
+			expectedImport: true,
+		},
+		{
+			name:           "skip a new package",
+			onExisting:     config.OnExistingSkip,
+			packageName:    "new",
+			expectedImport: true,
+		},
+		{
+			name:           "skip an existing package",
+			onExisting:     config.OnExistingSkip,
+			packageName:    "existing",
+			expectedImport: false,
+		},
+		{
+			name:          "fail on an existing package",
+			onExisting:    config.OnExistingFail,
+			packageName:   "existing",
+			expectedError: `existing 1.0.0:file.txt of import "import1" already exists in the destination`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodHead, r.Method)
+				if r.URL.Path != "/existing/1.0.0/file.txt" {
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(destination.Close)
+
+			pkgsImport := config.Import{
+				Type:        "generic",
+				OnExisting:  spec.onExisting,
+				Destination: config.Registry{URL: destination.URL},
+			}
+			registry, err := GetRegistry(pkgsImport, "import1")
+			require.NoError(t, err)
+
+			ok, err := ShouldImport(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", spec.packageName, "1.0.0:file.txt")
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				require.True(t, errors.Is(err, ErrAlreadyExists))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, spec.expectedImport, ok)
+		})
+	}
+}
+
+func TestResolveVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		importType    string
+		versions      []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:       "literal versions",
+			importType: "helm",
+			versions:   []string{"1.0.0", "1.0.0", "2.0.0"},
+			expected:   []string{"1.0.0", "2.0.0"},
+		},
+		{
+			name:       "version specs",
+			importType: "npm",
+			versions:   []string{"1.0.0", "latest:2", "^1"},
+			expected:   []string{"1.0.0", "1.1.0", "2.0.0"},
+		},
+		{
+			name:          "version spec without match",
+			importType:    "npm",
+			versions:      []string{">=3"},
+			expectedError: `no version of package in import "import1" matches ">=3"`,
+		},
+		{
+			name:          "version spec on an unsupported type",
+			importType:    "helm",
+			versions:      []string{"latest:2"},
+			expectedError: `the "helm" type of import "import1" doesn't support version specs like "latest:2" yet`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/package", r.URL.Path)
+				w.Write([]byte(`{"versions":{"1.0.0":{},"1.1.0":{},"2.0.0":{}}}`))
+			}))
+			t.Cleanup(source.Close)
+
+			pkgsImport := config.Import{Type: spec.importType, Source: config.Registry{URL: source.URL}}
+			registry, err := GetRegistry(pkgsImport, "import1")
+			require.NoError(t, err)
+
+			versions, err := ResolveVersions(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", "package", spec.versions)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, versions)
+		})
+	}
+}
+
+func TestExpandPackages(t *testing.T) {
+	tests := []struct {
+		name          string
+		importType    string
+		packages      map[string][]string
+		expected      map[string][]string
+		expectedError string
+	}{
+		{
+			name:       "without pattern",
+			importType: "helm",
+			packages:   map[string][]string{"package": {"1.0.0"}},
+			expected:   map[string][]string{"package": {"1.0.0"}},
+		},
+		{
+			name:       "scope pattern",
+			importType: "npm",
+			packages:   map[string][]string{"@acme/*": {"all"}, "@acme/first": {"1.0.0"}},
+			expected:   map[string][]string{"@acme/first": {"all", "1.0.0"}, "@acme/second": {"all"}},
+		},
+		{
+			name:       "name pattern",
+			importType: "npm",
+			packages:   map[string][]string{"@acme/s*": {"all"}},
+			expected:   map[string][]string{"@acme/second": {"all"}},
+		},
+		{
+			name:          "pattern without match",
+			importType:    "npm",
+			packages:      map[string][]string{"@acme/missing-*": {"all"}},
+			expectedError: `no package of import "import1" matches "@acme/missing-*"`,
+		},
+		{
+			name:          "pattern on an unsupported type",
+			importType:    "helm",
+			packages:      map[string][]string{"acme-*": {"all"}},
+			expectedError: `the "helm" type of import "import1" doesn't support package name patterns like "acme-*" yet`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/-/v1/search", r.URL.Path)
+				require.Equal(t, "scope:acme", r.URL.Query().Get("text"))
+				w.Write([]byte(`{"objects":[{"package":{"name":"@acme/first"}},{"package":{"name":"@acme/second"}},{"package":{"name":"@other/acme"}}],"total":3}`))
+			}))
+			t.Cleanup(source.Close)
+
+			pkgsImport := config.Import{Type: spec.importType, Source: config.Registry{URL: source.URL}}
+			registry, err := GetRegistry(pkgsImport, "import1")
+			require.NoError(t, err)
+
+			packages, err := ExpandPackages(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", spec.packages)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, packages)
+		})
+	}
+}
+
+func TestResolvePackages(t *testing.T) {
+	packuments := map[string]string{
+		"/first":  `{"versions":{"1.0.0":{"dependencies":{"second":"^2.0.0","git":"github:acme/git"}},"1.1.0":{}}}`,
+		"/second": `{"versions":{"2.0.0":{"dependencies":{"first":"1.0.0"}},"2.1.0":{"dependencies":{"third":"*"}},"3.0.0":{}}}`,
+		"/third":  `{"versions":{"1.0.0":{}}}`,
+	}
+
+	tests := []struct {
+		name                string
+		includeDependencies bool
+		packages            map[string][]string
+		expected            map[string][]string
+		expectedError       string
+	}{
+		{
+			name:     "without dependencies",
+			packages: map[string][]string{"first": {"1.0.0"}},
+			expected: map[string][]string{"first": {"1.0.0"}},
+		},
+		{
+			name:                "with dependencies",
+			includeDependencies: true,
+			packages:            map[string][]string{"first": {"1.0.0"}},
+			expected:            map[string][]string{"first": {"1.0.0"}, "second": {"2.1.0"}, "third": {"1.0.0"}},
+		},
+		{
+			name:                "with dependencies of version specs",
+			includeDependencies: true,
+			packages:            map[string][]string{"second": {"<2.1"}},
+			expected:            map[string][]string{"first": {"1.0.0"}, "second": {"2.0.0", "2.1.0"}, "third": {"1.0.0"}},
+		},
+		{
+			name:                "with a missing dependency",
+			includeDependencies: true,
+			packages:            map[string][]string{"missing": {"1.0.0"}},
+			expectedError:       `can't read the dependencies of missing 1.0.0 in import "import1": GET <source>/missing returned 404 Not Found`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				packument, ok := packuments[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(packument))
+			}))
+			t.Cleanup(source.Close)
+
+			pkgsImport := config.Import{Type: "npm", IncludeDependencies: spec.includeDependencies, Source: config.Registry{URL: source.URL}}
+			registry, err := GetRegistry(pkgsImport, "import1")
+			require.NoError(t, err)
+
+			packages, err := ResolvePackages(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", spec.packages)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, strings.Replace(spec.expectedError, "<source>", source.URL, 1))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, packages)
+		})
+	}
+}
+
+func TestResolveMavenPackages(t *testing.T) {
+	files := map[string]string{
+		"/com/example/app/maven-metadata.xml":          `<metadata><versioning><versions><version>1.0.Final</version><version>2.0.Final</version><version>3.0-beta1</version></versions></versioning></metadata>`,
+		"/com/example/app/2.0.Final/app-2.0.Final.pom": `<project><groupId>com.example</groupId><artifactId>app</artifactId><version>2.0.Final</version><dependencies><dependency><groupId>com.example</groupId><artifactId>lib</artifactId><version>[1.0,2.0)</version></dependency></dependencies></project>`,
+		"/com/example/lib/maven-metadata.xml":          `<metadata><versioning><versions><version>1.5-jre</version><version>1.10-jre</version><version>1.11-SNAPSHOT</version><version>2.0-jre</version></versions></versioning></metadata>`,
+		"/com/example/lib/1.10-jre/lib-1.10-jre.pom":   `<project><groupId>com.example</groupId><artifactId>lib</artifactId><version>1.10-jre</version></project>`,
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(source.Close)
+
+	pkgsImport := config.Import{Type: "maven", IncludeDependencies: true, Source: config.Registry{URL: source.URL}}
+	registry, err := GetRegistry(pkgsImport, "import1")
+	require.NoError(t, err)
+
+	packages, err := ResolvePackages(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", map[string][]string{"com.example:app": {"latest:1"}})
+
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"com.example:app": {"2.0.Final"}, "com.example:lib": {"1.10-jre"}}, packages)
+}
+
+func TestResolveNuGetPackages(t *testing.T) {
+	nuspec := `<package><metadata><dependencies>%s</dependencies></metadata></package>`
+	files := map[string]string{
+		"/flat/package.id/1.2.3/package.id.nuspec":            fmt.Sprintf(nuspec, `<dependency id="NEWTONSOFT.JSON" version="13.0.1" /><dependency id="Other.Package" version="2.0.0" />`),
+		"/flat/newtonsoft.json/13.0.1/newtonsoft.json.nuspec": fmt.Sprintf(nuspec, ""),
+		"/flat/other.package/2.0.0/other.package.nuspec":      fmt.Sprintf(nuspec, `<dependency id="newtonsoft.json" version="13.0.1" />`),
+	}
+
+	var source *httptest.Server
+	source = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.json" {
+			fmt.Fprintf(w, `{"resources":[{"@id":"%s/flat/","@type":"PackageBaseAddress/3.0.0"}]}`, source.URL)
+			return
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(source.Close)
+
+	pkgsImport := config.Import{Type: "nuget", IncludeDependencies: true, Source: config.Registry{URL: source.URL + "/index.json"}}
+	registry, err := GetRegistry(pkgsImport, "import1")
+	require.NoError(t, err)
+
+	packages, err := ResolvePackages(context.Background(), transfer.NewClient(nil), registry, pkgsImport, "import1", map[string][]string{"Package.Id": {"1.2.3"}, "Newtonsoft.Json": {"13.0.1"}})
+
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"Package.Id": {"1.2.3"}, "Newtonsoft.Json": {"13.0.1"}, "other.package": {"2.0.0"}}, packages)
+}
@@ -0,0 +1,150 @@
+// Package rpm is a set of simple functions that provide all the custom parts to handle
+// RPM package imports from a yum repository A to a registry B.
+package rpm
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a yum repository given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new RPM registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for RPM package imports.
+func (r *Registry) ImageName() string {
+	return "python:alpine"
+}
+
+const (
+	architectureSeparator = ":"
+	defaultArchitecture   = "x86_64"
+)
+
+// architectureRegexp tells an architecture apart from the rest of a version with an epoch, like 1:2.3-1.
+// Versions always contain a - between the version and the release while architectures never do.
+var architectureRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// AdditionalEnvVars returns the additional environment variables.
+// RPM package versions are in the form of [epoch:]version-release[:architecture].
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	if index := strings.LastIndex(version, architectureSeparator); index != -1 {
+		if architecture := version[index+1:]; architectureRegexp.MatchString(architecture) {
+			return map[string]string{"PACKAGE_ARCHITECTURE": architecture}
+		}
+	}
+
+	return map[string]string{"PACKAGE_ARCHITECTURE": defaultArchitecture}
+}
+
+// importScript resolves the package in the source primary metadata, downloads the .rpm file,
+// verifies its checksum and uploads it. See rpm_import.py for the details.
+//
+//go:embed rpm_import.py
+var importScript string
+
+const importScriptFile = "rpm_import.py"
+
+// Scripts returns the script lines to execute an RPM package import.
+// The source metadata is found through repodata/repomd.xml. The .rpm file is uploaded with a
+// multipart POST request to the destination url, like in the KhulnaSoft RPM API.
+func (r *Registry) Scripts() ([]string, error) {
+	scripts := make([]string, 0, 8)
+
+	scripts = append(scripts, fmt.Sprintf("cat > %s <<'EOF'\n%sEOF", importScriptFile, importScript))
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Source.Credentials, "RPM_SOURCE")...)
+	scripts = append(scripts, r.credentialsScripts(r.pkgsImport.Destination.Credentials, "RPM_DESTINATION")...)
+	scripts = append(scripts, fmt.Sprintf(`python3 %s "%s" "%s" "$PACKAGE_NAME" "$PACKAGE_VERSION" "$PACKAGE_ARCHITECTURE"`, importScriptFile, r.pkgsImport.Source.URL, r.pkgsImport.Destination.URL))
+
+	return scripts, nil
+}
+
+func (r *Registry) credentialsScripts(credentials config.Credentials, prefix string) []string {
+	return []string{
+		fmt.Sprintf(`export %s_TOKEN="%s"`, prefix, credentials.Token),
+		fmt.Sprintf(`export %s_USERNAME="%s"`, prefix, credentials.AdditionalParameters["username"]),
+		fmt.Sprintf(`export %s_HEADER_NAME="%s"`, prefix, credentials.AdditionalParameters["header_name"]),
+	}
+}
+
+func (r *Registry) validate(importName string) error {
+	if err := r.validateCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	if err := r.validateCredentials(r.pkgsImport.Destination.Credentials); err != nil {
+		return err
+	}
+
+	return r.validatePackages(importName)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://rpm-software-management.github.io/rpm/manual/spec.html#preamble-tags
+var (
+	rpmPackageNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._+-]+$`)
+	rpmVersionRegexp     = regexp.MustCompile(`^([0-9]+:)?[a-zA-Z0-9._+~^]+-[a-zA-Z0-9._+~^]+(:[a-z][a-z0-9_]*)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if !rpmPackageNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid RPM package name. It can only contain alphanumeric characters, ., _, + or -.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !rpmVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid RPM package version string. It must be in the form of : [epoch:]version-release[:architecture].", version)
+	}
+	return nil
+}
+
+var errInvalidCredentials = errors.New("RPM credentials require a token and a username or a token and a header_name for authenticated registries")
+
+func (r *Registry) validateCredentials(credentials config.Credentials) error {
+	if credentials.Token == "" || credentials.AdditionalParameters["username"] != "" || credentials.AdditionalParameters["header_name"] != "" {
+		return nil
+	}
+
+	return errInvalidCredentials
+}
@@ -0,0 +1,120 @@
+"""Copies an RPM package from a yum/dnf repository to a destination upload endpoint.
+
+Usage: python3 rpm_import.py <source url> <destination url> <name> <version[:architecture]> <architecture>
+
+The source url is the repository root, the directory containing repodata/repomd.xml.
+The version is [epoch:]version-release.
+Credentials are read from the RPM_{SOURCE,DESTINATION}_{TOKEN,USERNAME,HEADER_NAME} environment variables.
+See https://docs.pulpproject.org/pulp_rpm/workflows/metadata.html.
+"""
+
+import base64
+import bz2
+import gzip
+import hashlib
+import lzma
+import os
+import sys
+import urllib.request
+import uuid
+import xml.etree.ElementTree as ElementTree
+
+REPO_NAMESPACE = "{http://linux.duke.edu/metadata/repo}"
+COMMON_NAMESPACE = "{http://linux.duke.edu/metadata/common}"
+XML_BASE = "{http://www.w3.org/XML/1998/namespace}base"
+# Old repositories use sha as the checksum type for SHA-1.
+CHECKSUM_TYPES = {"sha": "sha1"}
+
+
+def auth_headers(prefix):
+    token = os.environ.get(prefix + "_TOKEN")
+    if not token:
+        return {}
+    username = os.environ.get(prefix + "_USERNAME")
+    if username:
+        return {"Authorization": "Basic " + base64.b64encode(("%s:%s" % (username, token)).encode()).decode()}
+    return {os.environ[prefix + "_HEADER_NAME"]: token}
+
+
+def open_url(url, prefix, data=None, headers=None, method="GET"):
+    all_headers = {"User-Agent": "pkgs_importer"}
+    all_headers.update(headers or {})
+    all_headers.update(auth_headers(prefix))
+    return urllib.request.urlopen(urllib.request.Request(url, data=data, headers=all_headers, method=method))
+
+
+def decompress(stream, href):
+    if href.endswith(".gz"):
+        return gzip.open(stream)
+    if href.endswith(".xz"):
+        return lzma.open(stream)
+    if href.endswith(".bz2"):
+        return bz2.open(stream)
+    if href.endswith(".zst"):
+        from compression import zstd
+
+        return zstd.open(stream)
+    return stream
+
+
+def primary_href(source):
+    with open_url(source + "/repodata/repomd.xml", "RPM_SOURCE") as response:
+        repomd = ElementTree.parse(response)
+    for data in repomd.iter(REPO_NAMESPACE + "data"):
+        if data.get("type") == "primary":
+            return data.find(REPO_NAMESPACE + "location").get("href")
+    sys.exit("no primary metadata found in %s" % source)
+
+
+def evr(version):
+    epoch = version.get("epoch", "0")
+    full_version = "%s-%s" % (version.get("ver"), version.get("rel"))
+    return {full_version, "%s:%s" % (epoch, full_version)}
+
+
+def find_package(source, name, version, architecture):
+    href = primary_href(source)
+    with open_url("%s/%s" % (source, href), "RPM_SOURCE") as response:
+        for _, element in ElementTree.iterparse(decompress(response, href)):
+            if element.tag != COMMON_NAMESPACE + "package":
+                continue
+            if (
+                element.findtext(COMMON_NAMESPACE + "name") == name
+                and element.findtext(COMMON_NAMESPACE + "arch") == architecture
+                and version in evr(element.find(COMMON_NAMESPACE + "version"))
+            ):
+                return element
+            element.clear()
+    sys.exit("version %s of package %s for %s not found in %s" % (version, name, architecture, source))
+
+
+def multipart(file_name, content):
+    boundary = uuid.uuid4().hex
+    body = (
+        "--%s\r\nContent-Disposition: form-data; name=\"file\"; filename=\"%s\"\r\n"
+        "Content-Type: application/x-rpm\r\n\r\n" % (boundary, file_name)
+    ).encode()
+    body += content + ("\r\n--%s--\r\n" % boundary).encode()
+    return body, "multipart/form-data; boundary=" + boundary
+
+
+def main(source, destination, name, version, architecture):
+    source = source.rstrip("/")
+    version = version.removesuffix(":" + architecture)
+    package = find_package(source, name, version, architecture)
+
+    checksum = package.find(COMMON_NAMESPACE + "checksum")
+    location = package.find(COMMON_NAMESPACE + "location")
+    base = location.get(XML_BASE, source).rstrip("/")
+    with open_url("%s/%s" % (base, location.get("href")), "RPM_SOURCE") as response:
+        rpm = response.read()
+    if hashlib.new(CHECKSUM_TYPES.get(checksum.get("type"), checksum.get("type")), rpm).hexdigest() != checksum.text:
+        sys.exit("checksum mismatch for package %s version %s" % (name, version))
+
+    body, content_type = multipart(os.path.basename(location.get("href")), rpm)
+    with open_url(destination, "RPM_DESTINATION", data=body, headers={"Content-Type": content_type}, method="POST") as response:
+        print(response.read().decode())
+
+
+if __name__ == "__main__":
+    main(*sys.argv[1:6])
@@ -0,0 +1,196 @@
+package rpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScripts(t *testing.T) {
+	pkgsImport := config.Import{
+		Source: config.Registry{
+			URL: "https://source.test/rocky/9/BaseOS/x86_64/os",
+		},
+		Destination: config.Registry{
+			URL: "https://destination.test/api/v4/projects/1/packages/rpm",
+			Credentials: config.Credentials{
+				Token:                "TOKEN_FOR_DESTINATION",
+				AdditionalParameters: map[string]string{"username": "username_for_destination"},
+			},
+		},
+	}
+
+	registry := Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	scripts, err := registry.Scripts()
+	assert.NoError(t, err)
+
+	require.Len(t, scripts, 8)
+	require.True(t, strings.HasPrefix(scripts[0], "cat > rpm_import.py <<'EOF'\n"))
+	require.True(t, strings.HasSuffix(scripts[0], "\nEOF"))
+	require.Contains(t, scripts[0], `"/repodata/repomd.xml"`)
+	require.Equal(t, []string{
+		`export RPM_SOURCE_TOKEN=""`,
+		`export RPM_SOURCE_USERNAME=""`,
+		`export RPM_SOURCE_HEADER_NAME=""`,
+		`export RPM_DESTINATION_TOKEN="TOKEN_FOR_DESTINATION"`,
+		`export RPM_DESTINATION_USERNAME="username_for_destination"`,
+		`export RPM_DESTINATION_HEADER_NAME=""`,
+		`python3 rpm_import.py "https://source.test/rocky/9/BaseOS/x86_64/os" "https://destination.test/api/v4/projects/1/packages/rpm" "$PACKAGE_NAME" "$PACKAGE_VERSION" "$PACKAGE_ARCHITECTURE"`,
+	}, scripts[1:])
+}
+
+func TestNewRegistry(t *testing.T) {
+	validDestination := config.Registry{
+		URL: "http://destination.registry",
+		Credentials: config.Credentials{
+			AdditionalParameters: map[string]string{
+				"username": "user",
+			},
+			Token: "1234567890",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		errorMessage string
+		pkgsImport   config.Import
+		pkgs         map[string][]string
+	}{
+		{
+			name: "with correct import type",
+			pkgsImport: config.Import{
+				Type: "rpm",
+			},
+		},
+		{
+			name: "with registries with correct credentials and packages",
+			pkgsImport: config.Import{
+				Type:        "rpm",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"bash":        {"5.1.8-6.el9_1", "5.1.8-6.el9_1:aarch64"},
+				"python3-pip": {"21.2.3-7.el9:noarch"},
+				"perl-Errno":  {"0:1.30-480.el9"},
+				"libstdc++":   {"1:11.3.1-4.3.el9:i686"},
+			},
+		},
+		{
+			name:         "with source registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type: "rpm",
+				Source: config.Registry{
+					URL: "http://source.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+				Destination: validDestination,
+			},
+		},
+		{
+			name:         "with destination registry with partial credentials",
+			errorMessage: errInvalidCredentials.Error(),
+			pkgsImport: config.Import{
+				Type:   "rpm",
+				Source: config.Registry{URL: "http://source.registry"},
+				Destination: config.Registry{
+					URL: "http://destination.registry",
+					Credentials: config.Credentials{
+						Token: "1234567890",
+					},
+				},
+			},
+		},
+		{
+			name:         "with invalid name",
+			errorMessage: "bash shell is an invalid RPM package name.",
+			pkgsImport: config.Import{
+				Type:        "rpm",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"bash shell": {"5.1.8-6.el9_1"},
+			},
+		},
+		{
+			name:         "with version without release",
+			errorMessage: "5.1.8 is an invalid RPM package version string. It must be in the form of : [epoch:]version-release[:architecture].",
+			pkgsImport: config.Import{
+				Type:        "rpm",
+				Source:      config.Registry{URL: "http://source.registry"},
+				Destination: validDestination,
+			},
+			pkgs: map[string][]string{
+				"bash": {"5.1.8"},
+			},
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set("import1.packages", spec.pkgs)
+
+			registry, err := NewRegistry(spec.pkgsImport, "import1")
+
+			if len(spec.errorMessage) != 0 {
+				require.Nil(t, registry)
+				require.ErrorContains(t, err, spec.errorMessage)
+			} else {
+				require.NotNil(t, registry)
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "python:alpine", new(Registry).ImageName())
+}
+
+func TestAdditionalEnvVars(t *testing.T) {
+	tests := []struct {
+		name                 string
+		version              string
+		expectedArchitecture string
+	}{
+		{
+			name:                 "without architecture",
+			version:              "5.1.8-6.el9_1",
+			expectedArchitecture: "x86_64",
+		},
+		{
+			name:                 "with architecture",
+			version:              "5.1.8-6.el9_1:noarch",
+			expectedArchitecture: "noarch",
+		},
+		{
+			name:                 "with epoch",
+			version:              "1:5.1.8-6.el9_1",
+			expectedArchitecture: "x86_64",
+		},
+		{
+			name:                 "with epoch and architecture",
+			version:              "1:5.1.8-6.el9_1:aarch64",
+			expectedArchitecture: "aarch64",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			expected := map[string]string{"PACKAGE_ARCHITECTURE": spec.expectedArchitecture}
+			require.Equal(t, expected, new(Registry).AdditionalEnvVars("bash", spec.version))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package rubygems is a set of simple functions that provide all the custom parts to handle
+// RubyGems package imports from registry A to registry B.
+package rubygems
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a RubyGems registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new RubyGems registry given an import.
+// Some validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validate(); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for RubyGems package imports.
+func (r *Registry) ImageName() string {
+	return "ruby:alpine"
+}
+
+// AdditionalEnvVars returns the additional environment variables.
+// The RubyGems scripts don't need any.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	return map[string]string{}
+}
+
+// Scripts returns the script lines to execute a RubyGems package import.
+// The usual gem commands are used. See:
+// - https://guides.rubygems.org/command-reference/#gem-fetch
+// - https://guides.rubygems.org/command-reference/#gem-push
+func (r *Registry) Scripts() ([]string, error) {
+	fetch, err := r.fetchScript()
+	if err != nil {
+		return nil, err
+	}
+
+	push, err := r.pushScript()
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		"mkdir _pkg",
+		"cd _pkg",
+		fetch,
+		push,
+	}, nil
+}
+
+func (r *Registry) fetchScript() (string, error) {
+	fullUrl, err := r.getFullUrl(r.pkgsImport.Source)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`gem fetch "$PACKAGE_NAME" --version "$PACKAGE_VERSION" --clear-sources --source %s`, fullUrl), nil
+}
+
+// pushScript uses basic auth when a username is given. Otherwise, the token is used as an API key.
+// See https://guides.rubygems.org/api-key-scopes/.
+func (r *Registry) pushScript() (string, error) {
+	destination := r.pkgsImport.Destination
+
+	if len(destination.Credentials.AdditionalParameters["username"]) == 0 {
+		return fmt.Sprintf(`GEM_HOST_API_KEY="%s" gem push $(ls *.gem | head -n 1) --host %s`, destination.Credentials.Token, destination.URL), nil
+	}
+
+	fullUrl, err := r.getFullUrl(destination)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`gem push $(ls *.gem | head -n 1) --host %s`, fullUrl), nil
+}
+
+func (r *Registry) getFullUrl(reg config.Registry) (string, error) {
+	address, err := url.Parse(reg.URL)
+	if err != nil {
+		return "", err
+	}
+
+	user, pw := reg.Credentials.AdditionalParameters["username"], reg.Credentials.Token
+	if len(user) != 0 && len(pw) != 0 {
+		address.User = url.UserPassword(user, pw)
+	}
+
+	return address.String(), nil
+}
+
+func (r *Registry) validate() error {
+	if err := r.validateSourceCredentials(r.pkgsImport.Source.Credentials); err != nil {
+		return err
+	}
+
+	return r.validateDestinationCredentials(r.pkgsImport.Destination.Credentials)
+}
+
+var (
+	errInvalidSourceCredentials      = errors.New("RubyGems source credentials require a token and a username in authenticated registries")
+	errInvalidDestinationCredentials = errors.New("RubyGems destination credentials require a token, used as GEM_HOST_API_KEY or as the password for the given username")
+)
+
+// validateSourceCredentials checks the source credentials. gem fetch can only use basic auth.
+func (r *Registry) validateSourceCredentials(credentials config.Credentials) error {
+	if len(credentials.Token) == 0 || len(credentials.AdditionalParameters["username"]) != 0 {
+		return nil
+	}
+
+	return errInvalidSourceCredentials
+}
+
+// validateDestinationCredentials checks the destination credentials. gem push can use an API key
+// or basic auth. In both cases, a token is needed.
+func (r *Registry) validateDestinationCredentials(credentials config.Credentials) error {
+	if len(credentials.AdditionalParameters["username"]) == 0 || len(credentials.Token) != 0 {
+		return nil
+	}
+
+	return errInvalidDestinationCredentials
+}
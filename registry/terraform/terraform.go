@@ -0,0 +1,182 @@
+// Package terraform is a set of simple functions that provide all the custom parts to handle
+// Terraform module imports from registry A to registry B. The providers, with the provider registry
+// protocol, are not supported yet: a KhulnaSoft registry can't be the destination of a provider.
+package terraform
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+)
+
+// Registry represents a Terraform module registry given an import.
+type Registry struct {
+	pkgsImport config.Import
+}
+
+// NewRegistry will create a new Terraform registry given an import.
+// Validations are executed and could return an error.
+func NewRegistry(pkgsImport config.Import, importName string) (*Registry, error) {
+	registry := &Registry{
+		pkgsImport: pkgsImport,
+	}
+
+	if err := registry.validatePackages(importName); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// ImageName returns the default image name for Terraform module imports.
+func (r *Registry) ImageName() string {
+	return "alpine:latest"
+}
+
+const moduleAddressSeparator = "/"
+
+// AdditionalEnvVars returns the additional environment variables.
+// Module addresses are in the form of namespace/name/system. The destination upload
+// only needs the name and the system.
+func (r *Registry) AdditionalEnvVars(name, version string) map[string]string {
+	parts := strings.Split(name, moduleAddressSeparator)
+	if len(parts) != 3 {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		"PACKAGE_MODULE_NAME":   parts[1],
+		"PACKAGE_MODULE_SYSTEM": parts[2],
+	}
+}
+
+// Scripts returns the script lines to execute a Terraform module import.
+// The source is accessed with the module registry protocol and the module archive is
+// uploaded to the destination with curl. See:
+// - https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+// - https://developer.hashicorp.com/terraform/internals/module-registry-protocol
+// - https://docs.khulnasoft.com/ee/user/packages/terraform_module_registry/
+func (r *Registry) Scripts() ([]string, error) {
+	source := r.pkgsImport.Source
+	sourceUrl := strings.TrimSuffix(source.URL, "/")
+
+	origin, err := r.origin(source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAuth := r.authOption(source.Credentials)
+
+	return []string{
+		"apk add --no-cache curl jq",
+		fmt.Sprintf(`export SOURCE_ORIGIN="%s"`, origin),
+		fmt.Sprintf(`modules_url=$(curl --fail --silent --show-error --location%s "%s/.well-known/terraform.json" | jq --raw-output '."modules.v1"')`, sourceAuth, sourceUrl),
+		fmt.Sprintf(`case "$modules_url" in http://*|https://*) ;; /*) modules_url="$SOURCE_ORIGIN$modules_url" ;; *) modules_url="%s/.well-known/$modules_url" ;; esac`, sourceUrl),
+		`download_url="${modules_url%/}/$PACKAGE_NAME/$PACKAGE_VERSION/download"`,
+		fmt.Sprintf(`archive_url=$(curl --fail --silent --show-error%s --output /dev/null --write-out "%%header{x-terraform-get}" "$download_url")`, sourceAuth),
+		resolveArchiveUrlScript,
+		r.downloadArchiveScript(sourceAuth),
+		r.uploadScript(),
+	}, nil
+}
+
+// resolveArchiveUrlScript resolves the X-Terraform-Get header of the download endpoint, a url that
+// can be relative to the url of the endpoint, like ./archive.tgz or /archives/module.tgz. The
+// go-getter addresses with a forced getter or another scheme, like git::https://..., are not
+// supported.
+const resolveArchiveUrlScript = `case "$archive_url" in ` +
+	`http://*|https://*) ;; ` +
+	`*::*|*://*) echo "unsupported module source: $archive_url" && exit 1 ;; ` +
+	`//*) archive_url="${download_url%%//*}$archive_url" ;; ` +
+	`/*) archive_url="$(echo "$download_url" | cut -d / -f 1-3)$archive_url" ;; ` +
+	`*) archive_url="${download_url%/*}/$archive_url" ;; ` +
+	`esac`
+
+// downloadArchiveScript downloads the module archive. Archives can be hosted outside of the
+// source registry. In that case, the credentials are not sent.
+func (r *Registry) downloadArchiveScript(sourceAuth string) string {
+	download := `curl --fail --silent --show-error --location%s --output module.tgz "$archive_url"`
+
+	if len(sourceAuth) == 0 {
+		return fmt.Sprintf(download, "")
+	}
+
+	return fmt.Sprintf(`if [ "${archive_url#$SOURCE_ORIGIN}" != "$archive_url" ]; then %s; else %s; fi`, fmt.Sprintf(download, sourceAuth), fmt.Sprintf(download, ""))
+}
+
+func (r *Registry) uploadScript() string {
+	destination := r.pkgsImport.Destination
+
+	return fmt.Sprintf(`curl --fail --silent --show-error%s --upload-file module.tgz "%s/$PACKAGE_MODULE_NAME/$PACKAGE_MODULE_SYSTEM/$PACKAGE_VERSION/file"`, r.authOption(destination.Credentials), strings.TrimSuffix(destination.URL, "/"))
+}
+
+func (r *Registry) origin(rawUrl string) (string, error) {
+	address, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s://%s", address.Scheme, address.Host), nil
+}
+
+// authOption sends the token as a bearer token, like the Terraform CLI does, unless a
+// header_name is given.
+func (r *Registry) authOption(credentials config.Credentials) string {
+	if len(credentials.Token) == 0 {
+		return ""
+	}
+
+	if headerName := credentials.AdditionalParameters["header_name"]; len(headerName) != 0 {
+		return fmt.Sprintf(` --header "%s: %s"`, headerName, credentials.Token)
+	}
+
+	return fmt.Sprintf(` --header "Authorization: Bearer %s"`, credentials.Token)
+}
+
+func (r *Registry) validatePackages(importName string) error {
+	packagesMap, err := config.GetPackagesMap(importName)
+	if err != nil {
+		return err
+	}
+
+	for packageName, packageVersions := range packagesMap {
+		if err := r.validatePackageName(packageName); err != nil {
+			return err
+		}
+		for _, packageVersion := range packageVersions {
+			if err := r.validatePackageVersion(packageVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// See https://developer.hashicorp.com/terraform/internals/module-registry-protocol#module-addresses
+var (
+	moduleAddressRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*/[a-zA-Z0-9][a-zA-Z0-9_-]*/[a-zA-Z0-9]+$`)
+	// See https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#provider-addresses
+	providerAddressRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*/[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+	moduleVersionRegexp   = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?$`)
+)
+
+func (r *Registry) validatePackageName(name string) error {
+	if providerAddressRegexp.MatchString(name) {
+		return fmt.Errorf("%s looks like a Terraform provider address. Only modules, in the form of namespace/name/system, can be imported.", name)
+	}
+	if !moduleAddressRegexp.MatchString(name) {
+		return fmt.Errorf("%s is an invalid Terraform module address. It must be in the form of : namespace/name/system.", name)
+	}
+	return nil
+}
+
+func (r *Registry) validatePackageVersion(version string) error {
+	if !moduleVersionRegexp.MatchString(version) {
+		return fmt.Errorf("%s is an invalid Terraform module version. It must be a semantic version like 1.2.3.", version)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+// Package transfer provides the HTTP building blocks of the in-process imports: authenticated
+// downloads, uploads and checksum verifications between a source and a destination registry.
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"golang.org/x/exp/slices"
+)
+
+const userAgent = "pkgs_importer"
+
+// Auth adds the authentication of a registry to a request. A nil Auth sends anonymous requests.
+type Auth func(*http.Request)
+
+// BasicAuth authenticates requests with a username and a password.
+func BasicAuth(username, password string) Auth {
+	return func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+// HeaderAuth authenticates requests with a custom header.
+func HeaderAuth(name, value string) Auth {
+	return func(req *http.Request) {
+		req.Header.Set(name, value)
+	}
+}
+
+// BearerAuth authenticates requests with a bearer token.
+func BearerAuth(token string) Auth {
+	return HeaderAuth("Authorization", "Bearer "+token)
+}
+
+// ExpandCredentials replaces the $VAR and ${VAR} references of the token and the additional
+// parameters with the value of the environment variables. The pipeline scripts leave the expansion
+// to the shell, the in-process requests must do it themselves.
+func ExpandCredentials(credentials config.Credentials) config.Credentials {
+	expanded := config.Credentials{Token: os.ExpandEnv(credentials.Token)}
+	if credentials.AdditionalParameters != nil {
+		expanded.AdditionalParameters = make(map[string]string, len(credentials.AdditionalParameters))
+		for name, value := range credentials.AdditionalParameters {
+			expanded.AdditionalParameters[name] = os.ExpandEnv(value)
+		}
+	}
+
+	return expanded
+}
+
+// CredentialsAuth returns the authentication described by the usual credentials of a registry:
+// a token with a username is used for a basic authentication, a token with a header_name is sent in
+// that header and a token alone is sent as a bearer token. Environment variables are expanded.
+func CredentialsAuth(credentials config.Credentials) Auth {
+	credentials = ExpandCredentials(credentials)
+	if len(credentials.Token) == 0 {
+		return nil
+	}
+
+	if username := credentials.AdditionalParameters["username"]; len(username) != 0 {
+		return BasicAuth(username, credentials.Token)
+	}
+
+	if headerName := credentials.AdditionalParameters["header_name"]; len(headerName) != 0 {
+		return HeaderAuth(headerName, credentials.Token)
+	}
+
+	return BearerAuth(credentials.Token)
+}
+
+// SameHost returns true when both urls are valid and have the same host. It tells whether
+// credentials can be sent to a url listed by a registry.
+func SameHost(rawUrl, otherRawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+
+	other, err := url.Parse(otherRawUrl)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == other.Host
+}
+
+// StatusError is returned when a registry answers with a non successful status code.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s returned %s", e.Method, e.URL, e.Status)
+}
+
+// IsNotFound returns true when the error is a StatusError for a 404 response.
+func IsNotFound(err error) bool {
+	var statusError *StatusError
+	return errors.As(err, &statusError) && statusError.StatusCode == http.StatusNotFound
+}
+
+// Client executes the requests of the in-process imports.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient will create a new Client. A nil http.Client uses http.DefaultClient. The headers set by
+// an Auth are not sent when a request is redirected to another host.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	client := *httpClient
+	client.CheckRedirect = dropAuthOnRedirect(httpClient.CheckRedirect)
+
+	return &Client{
+		httpClient: &client,
+	}
+}
+
+// authHeadersKey is the context key of the names of the headers set by the Auth of a request.
+type authHeadersKey struct{}
+
+// dropAuthOnRedirect removes the headers set by an Auth from the requests redirected to another
+// host than the original one before calling checkRedirect. Only the Authorization header is removed
+// by the http package, not custom ones like Private-Token or X-NuGet-ApiKey.
+func dropAuthOnRedirect(checkRedirect func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != via[0].URL.Host {
+			names, _ := req.Context().Value(authHeadersKey{}).([]string)
+			for _, name := range names {
+				req.Header.Del(name)
+			}
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		return nil
+	}
+}
+
+// Get downloads the content at the given url.
+func (c *Client) Get(ctx context.Context, url string, auth Auth, headers ...string) ([]byte, error) {
+	return c.Do(ctx, http.MethodGet, url, auth, nil, headers...)
+}
+
+// GetJSON downloads the content at the given url and decodes it into value.
+func (c *Client) GetJSON(ctx context.Context, url string, auth Auth, value interface{}) error {
+	body, err := c.Get(ctx, url, auth, "Accept", "application/json")
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("invalid JSON document at %s: %w", url, err)
+	}
+
+	return nil
+}
+
+// Exists sends a HEAD request to the given url. It returns false for a 404 response.
+func (c *Client) Exists(ctx context.Context, url string, auth Auth) (bool, error) {
+	_, err := c.Do(ctx, http.MethodHead, url, auth, nil)
+	if IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Do executes a request and returns the response body. headers is a list of name and value pairs.
+// Redirections are followed and a non successful status code is returned as a StatusError. The
+// response is held in memory: use Download for the files of the packages.
+func (c *Client) Do(ctx context.Context, method, url string, auth Auth, body []byte, headers ...string) ([]byte, error) {
+	var reader Body
+	if body != nil {
+		reader = func() io.Reader { return bytes.NewReader(body) }
+	}
+
+	resp, err := c.send(ctx, method, url, auth, reader, int64(len(body)), headers...)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// Download saves the content at the given url to a temporary file instead of holding it in memory.
+// The returned File must be closed.
+func (c *Client) Download(ctx context.Context, url string, auth Auth, headers ...string) (*File, error) {
+	resp, err := c.send(ctx, http.MethodGet, url, auth, nil, 0, headers...)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp("", "pkgs_importer-*")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{
+		content: tmp,
+		close: func() error {
+			tmp.Close()
+			return os.Remove(tmp.Name())
+		},
+	}
+
+	file.size, err = io.Copy(tmp, resp.Body)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("download of %s failed: %w", url, err)
+	}
+
+	return file, nil
+}
+
+// Body returns a new reader of the content of a request from the start each time it's called, for
+// the request to be sent again on a redirection with a 307 or 308 status code.
+type Body func() io.Reader
+
+// Upload streams the body of the given size with a request, like a PUT or a POST. headers is a
+// list of name and value pairs.
+func (c *Client) Upload(ctx context.Context, method, url string, auth Auth, body Body, size int64, headers ...string) error {
+	resp, err := c.send(ctx, method, url, auth, body, size, headers...)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// send executes a request and returns the response of a successful status code. The response body
+// must be closed.
+func (c *Client) send(ctx context.Context, method, url string, auth Auth, body Body, size int64, headers ...string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = body()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(body()), nil
+		}
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	if auth != nil {
+		req = withAuth(req, auth)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, &StatusError{Method: method, URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return resp, nil
+}
+
+// File is the content of a package file, downloaded to a temporary file or held in memory.
+type File struct {
+	content io.ReaderAt
+	size    int64
+	close   func() error
+}
+
+// NewFile will create a File of a content held in memory, like a generated metadata file.
+func NewFile(content []byte) *File {
+	return &File{
+		content: bytes.NewReader(content),
+		size:    int64(len(content)),
+		close:   func() error { return nil },
+	}
+}
+
+// Size returns the number of bytes of the file.
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// Reader returns a reader of the whole content. Each call returns a new reader from the start.
+func (f *File) Reader() *io.SectionReader {
+	return io.NewSectionReader(f.content, 0, f.size)
+}
+
+// Body returns the Body of an upload of the content.
+func (f *File) Body() Body {
+	return func() io.Reader { return f.Reader() }
+}
+
+// Close removes the temporary file of a download.
+func (f *File) Close() error {
+	return f.close()
+}
+
+// Checksum returns the hexadecimal digest of the content for the given algorithm: md5, sha1,
+// sha256 or sha512.
+func (f *File) Checksum(algorithm string) (string, error) {
+	return checksum(algorithm, f.Reader())
+}
+
+// VerifyChecksum returns an error when the hexadecimal digest of the content doesn't match the
+// expected one.
+func (f *File) VerifyChecksum(algorithm, expected, fileName string) error {
+	return verifyChecksum(algorithm, f.Reader(), expected, fileName)
+}
+
+// withAuth applies the auth to the request and records the names of the headers it set, for them
+// to be dropped on redirections to another host.
+func withAuth(req *http.Request, auth Auth) *http.Request {
+	before := req.Header.Clone()
+	auth(req)
+
+	var names []string
+	for name, values := range req.Header {
+		if !slices.Equal(before[name], values) {
+			names = append(names, name)
+		}
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), authHeadersKey{}, names))
+}
+
+// FormField is a text field of a multipart form.
+type FormField struct {
+	Name, Value string
+}
+
+// MultipartForm encodes the fields and a file in a multipart form. It returns the body, streaming
+// the file, its size and its content type.
+func MultipartForm(fields []FormField, fileField, fileName string, file *File) (Body, int64, string, error) {
+	head := new(bytes.Buffer)
+	writer := multipart.NewWriter(head)
+
+	for _, field := range fields {
+		if err := writer.WriteField(field.Name, field.Value); err != nil {
+			return nil, 0, "", err
+		}
+	}
+
+	if _, err := writer.CreateFormFile(fileField, fileName); err != nil {
+		return nil, 0, "", err
+	}
+	headSize := head.Len()
+
+	// The closing boundary is written after the part header, it's sent after the file.
+	if err := writer.Close(); err != nil {
+		return nil, 0, "", err
+	}
+	tail := append([]byte(nil), head.Bytes()[headSize:]...)
+	head.Truncate(headSize)
+
+	body := func() io.Reader {
+		return io.MultiReader(bytes.NewReader(head.Bytes()), file.Reader(), bytes.NewReader(tail))
+	}
+
+	return body, int64(head.Len()+len(tail)) + file.Size(), writer.FormDataContentType(), nil
+}
+
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Checksum returns the hexadecimal digest of the content for the given algorithm: md5, sha1,
+// sha256 or sha512.
+func Checksum(algorithm string, content []byte) (string, error) {
+	return checksum(algorithm, bytes.NewReader(content))
+}
+
+// VerifyChecksum returns an error when the hexadecimal digest of the content doesn't match the
+// expected one.
+func VerifyChecksum(algorithm string, content []byte, expected, fileName string) error {
+	return verifyChecksum(algorithm, bytes.NewReader(content), expected, fileName)
+}
+
+func checksum(algorithm string, content io.Reader) (string, error) {
+	newHash, ok := hashes[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+
+	h := newHash()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func verifyChecksum(algorithm string, content io.Reader, expected, fileName string) error {
+	checksum, err := checksum(algorithm, content)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(checksum, expected) {
+		return fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s", algorithm, fileName, expected, checksum)
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package transfer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		credentials    config.Credentials
+		expectedHeader string
+		expectedValue  string
+	}{
+		{
+			name:        "anonymous",
+			credentials: config.Credentials{},
+		},
+		{
+			name: "username",
+			credentials: config.Credentials{
+				Token:                "TOKEN",
+				AdditionalParameters: map[string]string{"username": "user"},
+			},
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic dXNlcjpUT0tFTg==",
+		},
+		{
+			name: "header name",
+			credentials: config.Credentials{
+				Token:                "TOKEN",
+				AdditionalParameters: map[string]string{"header_name": "Private-Token"},
+			},
+			expectedHeader: "Private-Token",
+			expectedValue:  "TOKEN",
+		},
+		{
+			name:           "token only",
+			credentials:    config.Credentials{Token: "TOKEN"},
+			expectedHeader: "Authorization",
+			expectedValue:  "Bearer TOKEN",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			auth := CredentialsAuth(spec.credentials)
+
+			if len(spec.expectedHeader) == 0 {
+				require.Nil(t, auth)
+				return
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "http://registry.test", nil)
+			auth(req)
+			require.Equal(t, spec.expectedValue, req.Header.Get(spec.expectedHeader))
+		})
+	}
+}
+
+func TestCredentialsAuthExpandsEnvironmentVariables(t *testing.T) {
+	t.Setenv("DESTINATION_TOKEN", "secret")
+	t.Setenv("DESTINATION_USER", "user")
+
+	tests := []struct {
+		name           string
+		credentials    config.Credentials
+		expectedHeader string
+		expectedValue  string
+	}{
+		{
+			name:           "token",
+			credentials:    config.Credentials{Token: "$DESTINATION_TOKEN"},
+			expectedHeader: "Authorization",
+			expectedValue:  "Bearer secret",
+		},
+		{
+			name: "username and braced token",
+			credentials: config.Credentials{
+				Token:                "${DESTINATION_TOKEN}",
+				AdditionalParameters: map[string]string{"username": "$DESTINATION_USER"},
+			},
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic dXNlcjpzZWNyZXQ=",
+		},
+		{
+			name: "header name",
+			credentials: config.Credentials{
+				Token:                "$DESTINATION_TOKEN",
+				AdditionalParameters: map[string]string{"header_name": "Private-Token"},
+			},
+			expectedHeader: "Private-Token",
+			expectedValue:  "secret",
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Get(spec.expectedHeader)
+			}))
+			t.Cleanup(server.Close)
+
+			_, err := NewClient(nil).Get(context.Background(), server.URL, CredentialsAuth(spec.credentials))
+			require.NoError(t, err)
+			require.Equal(t, spec.expectedValue, received)
+		})
+	}
+
+	require.Nil(t, CredentialsAuth(config.Credentials{Token: "$UNSET_DESTINATION_TOKEN"}))
+}
+
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, userAgent, r.Header.Get("User-Agent"))
+
+		switch r.URL.Path {
+		case "/file":
+			w.Write([]byte("content"))
+		case "/json":
+			require.Equal(t, "application/json", r.Header.Get("Accept"))
+			w.Write([]byte(`{"name":"package"}`))
+		case "/upload":
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+			require.Equal(t, "Bearer TOKEN", r.Header.Get("Authorization"))
+			body, _ := io.ReadAll(r.Body)
+			require.Equal(t, "uploaded", string(body))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	content, err := client.Get(ctx, server.URL+"/file", nil)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+
+	var document struct{ Name string }
+	require.NoError(t, client.GetJSON(ctx, server.URL+"/json", nil, &document))
+	require.Equal(t, "package", document.Name)
+
+	_, err = client.Do(ctx, http.MethodPut, server.URL+"/upload", BearerAuth("TOKEN"), []byte("uploaded"), "Content-Type", "text/plain")
+	require.NoError(t, err)
+
+	exists, err := client.Exists(ctx, server.URL+"/file", nil)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = client.Exists(ctx, server.URL+"/missing", nil)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = client.Get(ctx, server.URL+"/missing", nil)
+	require.EqualError(t, err, "GET "+server.URL+"/missing returned 404 Not Found")
+	require.True(t, IsNotFound(err))
+}
+
+func TestDoRedirect(t *testing.T) {
+	var otherHeaders http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherHeaders = r.Header.Clone()
+		w.Write([]byte("other content"))
+	}))
+	t.Cleanup(other.Close)
+
+	var sameHeaders http.Header
+	var registry *httptest.Server
+	registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/other":
+			http.Redirect(w, r, other.URL+"/file", http.StatusFound)
+		case "/same":
+			http.Redirect(w, r, registry.URL+"/file", http.StatusFound)
+		case "/file":
+			sameHeaders = r.Header.Clone()
+			w.Write([]byte("content"))
+		}
+	}))
+	t.Cleanup(registry.Close)
+
+	tests := []struct {
+		name string
+		auth Auth
+	}{
+		{name: "custom header", auth: HeaderAuth("Private-Token", "TOKEN")},
+		{name: "api key", auth: HeaderAuth("X-NuGet-ApiKey", "TOKEN")},
+		{name: "basic", auth: BasicAuth("user", "TOKEN")},
+		{name: "bearer", auth: BearerAuth("TOKEN")},
+	}
+
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://registry.test", nil)
+			spec.auth(req)
+			expected := req.Header
+
+			content, err := client.Get(ctx, registry.URL+"/other", spec.auth, "Accept", "text/plain")
+			require.NoError(t, err)
+			require.Equal(t, "other content", string(content))
+			require.Equal(t, "text/plain", otherHeaders.Get("Accept"))
+			for name := range expected {
+				require.Empty(t, otherHeaders.Values(name), name)
+			}
+
+			content, err = client.Get(ctx, registry.URL+"/same", spec.auth)
+			require.NoError(t, err)
+			require.Equal(t, "content", string(content))
+			for name, values := range expected {
+				require.Equal(t, values, sameHeaders.Values(name), name)
+			}
+		})
+	}
+
+	require.Nil(t, http.DefaultClient.CheckRedirect)
+}
+
+func TestDownloadAndUpload(t *testing.T) {
+	content := strings.Repeat("content", 10000)
+	uploaded := new(strings.Builder)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file":
+			w.Write([]byte(content))
+		case "/upload":
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, int64(len(content)), r.ContentLength)
+			io.Copy(uploaded, r.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	file, err := client.Download(ctx, server.URL+"/file", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), file.Size())
+	require.NoError(t, file.VerifyChecksum("sha1", "973ed66f316d79fe4c55eac02f2181fa30f55b9c", "file"))
+
+	require.NoError(t, client.Upload(ctx, http.MethodPut, server.URL+"/upload", nil, file.Body(), file.Size()))
+	require.Equal(t, content, uploaded.String())
+
+	tmpFile := file.content.(*os.File).Name()
+	require.NoError(t, file.Close())
+	require.NoFileExists(t, tmpFile)
+
+	_, err = client.Download(ctx, server.URL+"/missing", nil)
+	require.EqualError(t, err, "GET "+server.URL+"/missing returned 404 Not Found")
+
+	err = client.Upload(ctx, http.MethodPut, server.URL+"/missing", nil, NewFile([]byte("content")).Body(), 7)
+	require.EqualError(t, err, "PUT "+server.URL+"/missing returned 404 Not Found")
+}
+
+func TestUploadRedirect(t *testing.T) {
+	content := strings.Repeat("content", 10000)
+	uploaded := new(strings.Builder)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/temporary":
+			http.Redirect(w, r, "/file", http.StatusTemporaryRedirect)
+		case "/permanent":
+			http.Redirect(w, r, "/file", http.StatusPermanentRedirect)
+		case "/file":
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, int64(len(content)), r.ContentLength)
+			uploaded.Reset()
+			io.Copy(uploaded, r.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(nil)
+	ctx := context.Background()
+	file := NewFile([]byte(content))
+
+	for _, path := range []string{"/temporary", "/permanent"} {
+		t.Run(path, func(t *testing.T) {
+			uploaded.Reset()
+			require.NoError(t, client.Upload(ctx, http.MethodPut, server.URL+path, nil, file.Body(), file.Size()))
+			require.Equal(t, content, uploaded.String())
+		})
+	}
+}
+
+func TestMultipartForm(t *testing.T) {
+	body, size, contentType, err := MultipartForm([]FormField{{Name: "name", Value: "package"}}, "content", "package.tgz", NewFile([]byte("archive")))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", mediaType)
+
+	encoded, err := io.ReadAll(body())
+	require.NoError(t, err)
+	require.Equal(t, int64(len(encoded)), size)
+
+	form, err := multipart.NewReader(strings.NewReader(string(encoded)), params["boundary"]).ReadForm(1024)
+	require.NoError(t, err)
+	require.Equal(t, []string{"package"}, form.Value["name"])
+	require.Equal(t, "package.tgz", form.File["content"][0].Filename)
+
+	file, err := form.File["content"][0].Open()
+	require.NoError(t, err)
+	archive, _ := io.ReadAll(file)
+	require.Equal(t, "archive", string(archive))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	content := []byte("content")
+
+	require.NoError(t, VerifyChecksum("sha256", content, "ED7002B439E9AC845F22357D822BAC1444730FBDB6016D3EC9432297B9EC9F73", "file"))
+	require.EqualError(t, VerifyChecksum("sha1", content, "0000", "file"), "sha1 checksum mismatch for file: expected 0000, got 040f06fd774092478d450774f5ba30c5da78acc8")
+	require.EqualError(t, VerifyChecksum("crc32", content, "0000", "file"), `unsupported checksum algorithm "crc32"`)
+}
+
+func TestSameHost(t *testing.T) {
+	require.True(t, SameHost("https://registry.test/files/package.tgz", "https://registry.test/api/"))
+	require.False(t, SameHost("https://files.test/package.tgz", "https://registry.test/api/"))
+	require.False(t, SameHost("%", "https://registry.test/api/"))
+}
@@ -0,0 +1,336 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The Maven qualifiers, from the oldest to the newest. Unknown qualifiers are more recent than
+// all of them and sorted in lexical order. The empty qualifier is a release.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// CompareMaven compares two Maven versions with the ordering of the Maven ComparableVersion, like
+// 1.0-alpha-1 < 1.0-rc1 < 1.0 = 1.0.0.Final < 1.0-sp < 1.0.1. It returns -1, 0 or 1 when a is
+// older, equal or more recent than b. See
+// https://maven.apache.org/pom.html#version-order-specification.
+func CompareMaven(a, b string) int {
+	return parseMaven(a).compare(parseMaven(b))
+}
+
+// IsMavenSnapshot returns true for a SNAPSHOT version, that is neither a release nor a prerelease.
+func IsMavenSnapshot(version string) bool {
+	return strings.HasSuffix(version, "-SNAPSHOT")
+}
+
+// IsMavenRelease returns true for a version without an alpha, beta, milestone, rc or snapshot
+// qualifier, like 31.1-jre, 6.2.7.Final or 5.3.30.RELEASE.
+func IsMavenRelease(version string) bool {
+	return !parseMaven(version).prerelease()
+}
+
+// mavenOperators are the comparison operators of the Maven constraints, the longest first.
+var mavenOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// mavenMatcher parses a constraint of comparisons, like >=1.0, <2.0, that must all match. The
+// comparisons are separated by commas or spaces and the alternatives by ||.
+func mavenMatcher(constraint string) (func(string) bool, error) {
+	type comparison struct{ operator, version string }
+	alternatives := [][]comparison{}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		comparisons := []comparison{}
+		for _, field := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if field == All {
+				continue
+			}
+
+			c := comparison{operator: "=", version: field}
+			for _, operator := range mavenOperators {
+				if strings.HasPrefix(field, operator) {
+					c = comparison{operator: operator, version: strings.TrimPrefix(field, operator)}
+					break
+				}
+			}
+			if len(c.version) == 0 || strings.ContainsAny(c.version, rangeOperators) {
+				return nil, fmt.Errorf("improper Maven constraint: %s", field)
+			}
+			comparisons = append(comparisons, c)
+		}
+		alternatives = append(alternatives, comparisons)
+	}
+
+	return func(version string) bool {
+		for _, comparisons := range alternatives {
+			matches := true
+			for _, c := range comparisons {
+				result := CompareMaven(version, c.version)
+				switch c.operator {
+				case ">=":
+					matches = result >= 0
+				case "<=":
+					matches = result <= 0
+				case "!=":
+					matches = result != 0
+				case ">":
+					matches = result > 0
+				case "<":
+					matches = result < 0
+				default:
+					matches = result == 0
+				}
+				if !matches {
+					break
+				}
+			}
+			if matches {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// mavenItem is a part of a parsed Maven version. A nil item stands for a missing part.
+type mavenItem interface {
+	compare(other mavenItem) int
+	isNull() bool
+}
+
+type mavenInt string
+
+type mavenString string
+
+type mavenList []mavenItem
+
+// parseMaven splits a version into numbers and qualifiers. A dash or a change between digits and
+// letters starts a sub list, a dot separates the items of a list.
+func parseMaven(version string) mavenList {
+	version = strings.ToLower(version)
+
+	root := &mavenList{}
+	list := root
+	stack := []*mavenList{root}
+	digit := false
+	start := 0
+
+	newList := func() {
+		sub := &mavenList{}
+		list.add(sub)
+		list = sub
+		stack = append(stack, sub)
+	}
+
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.add(mavenInt("0"))
+			} else {
+				list.add(parseMavenItem(digit, version[start:i], false))
+			}
+			start = i + 1
+			if c == '-' {
+				newList()
+			}
+		case c >= '0' && c <= '9':
+			if !digit && i > start {
+				list.add(parseMavenItem(false, version[start:i], true))
+				start = i
+				newList()
+			}
+			digit = true
+		default:
+			if digit && i > start {
+				list.add(parseMavenItem(true, version[start:i], false))
+				start = i
+				newList()
+			}
+			digit = false
+		}
+	}
+	if len(version) > start {
+		list.add(parseMavenItem(digit, version[start:], false))
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+
+	return *root
+}
+
+// parseMavenItem returns a number or a qualifier. A single letter followed by digits is short for a
+// qualifier, like a1 for alpha-1.
+func parseMavenItem(digit bool, value string, followedByDigit bool) mavenItem {
+	if digit {
+		value = strings.TrimLeft(value, "0")
+		if len(value) == 0 {
+			value = "0"
+		}
+		return mavenInt(value)
+	}
+
+	if followedByDigit && len(value) == 1 {
+		switch value {
+		case "a":
+			value = "alpha"
+		case "b":
+			value = "beta"
+		case "m":
+			value = "milestone"
+		}
+	}
+	if alias, ok := mavenAliases[value]; ok {
+		value = alias
+	}
+
+	return mavenString(value)
+}
+
+func (i mavenInt) isNull() bool {
+	return i == "0"
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch other := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		// The numbers can overflow an int64, they are compared by length first.
+		if len(i) != len(other) {
+			return sign(len(i) - len(other))
+		}
+		return strings.Compare(string(i), string(other))
+	default:
+		return 1
+	}
+}
+
+func (s mavenString) isNull() bool {
+	return len(s) == 0
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch other := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), mavenString("").comparable())
+	case mavenString:
+		return strings.Compare(s.comparable(), other.comparable())
+	default:
+		return -1
+	}
+}
+
+// comparable returns the index of a known qualifier or, for an unknown one, a string sorted after all
+// the indexes.
+func (s mavenString) comparable() string {
+	for index, qualifier := range mavenQualifiers {
+		if string(s) == qualifier {
+			return strconv.Itoa(index)
+		}
+	}
+
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + string(s)
+}
+
+func (l *mavenList) add(item mavenItem) {
+	*l = append(*l, item)
+}
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+// normalize removes the trailing null items, like the zeros of 1.0.0 or the empty qualifier of
+// 1.0-final. It stops at the first non null number or qualifier.
+func (l *mavenList) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, ok := item.(*mavenList); !ok {
+			break
+		}
+	}
+}
+
+func (l mavenList) compare(other mavenItem) int {
+	switch other := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case *mavenList:
+		return l.compareList(*other)
+	case mavenList:
+		return l.compareList(other)
+	}
+
+	return 0
+}
+
+func (l mavenList) compareList(other mavenList) int {
+	for i := 0; i < len(l) || i < len(other); i++ {
+		var left, right mavenItem
+		if i < len(l) {
+			left = l[i]
+		}
+		if i < len(other) {
+			right = other[i]
+		}
+
+		var result int
+		if left == nil {
+			result = -right.compare(nil)
+		} else {
+			result = left.compare(right)
+		}
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+// prerelease returns true when the first qualifier of the version is older than a release.
+func (l mavenList) prerelease() bool {
+	for _, item := range l {
+		switch item := item.(type) {
+		case mavenString:
+			return item.compare(nil) < 0
+		case *mavenList:
+			return item.prerelease()
+		}
+	}
+
+	return false
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,78 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareMaven(t *testing.T) {
+	// Each version is older than the next one.
+	ordered := []string{
+		"1-alpha-1",
+		"1-alpha2",
+		"1.0-beta1",
+		"1.0-b2",
+		"1-milestone-1",
+		"1.0-rc1",
+		"1.0-cr2",
+		"1.0-SNAPSHOT",
+		"1",
+		"1.0-sp",
+		"1-abc",
+		"1-jre",
+		"1-xyz",
+		"1.0.1",
+		"1.1-jre",
+		"1.2",
+		"1.10",
+		"31.1-android",
+		"31.1-jre",
+		"31.10-jre",
+		"100000000000000000000",
+	}
+
+	for i := 0; i+1 < len(ordered); i++ {
+		older, newer := ordered[i], ordered[i+1]
+		require.Equal(t, -1, CompareMaven(older, newer), "%s < %s", older, newer)
+		require.Equal(t, 1, CompareMaven(newer, older), "%s > %s", newer, older)
+	}
+
+	equal := [][2]string{
+		{"1", "1.0.0"},
+		{"1.0", "1-final"},
+		{"6.2.7.Final", "6.2.7"},
+		{"5.3.30.RELEASE", "5.3.30.ga"},
+		{"1.0-rc1", "1.0-CR1"},
+		{"1.0-alpha1", "1.0-a1"},
+		{"1.01", "1.1"},
+	}
+
+	for _, versions := range equal {
+		require.Equal(t, 0, CompareMaven(versions[0], versions[1]), "%s = %s", versions[0], versions[1])
+	}
+}
+
+func TestIsMavenRelease(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "1.2.3", expected: true},
+		{version: "31.1-jre", expected: true},
+		{version: "6.2.7.Final", expected: true},
+		{version: "5.3.30.RELEASE", expected: true},
+		{version: "1.0-sp1", expected: true},
+		{version: "1.0-SNAPSHOT", expected: false},
+		{version: "2.0.0-M1", expected: false},
+		{version: "1.0-beta-2", expected: false},
+		{version: "6.0.0.CR1", expected: false},
+		{version: "1.0-alpha1", expected: false},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.version, func(t *testing.T) {
+			require.Equal(t, spec.expected, IsMavenRelease(spec.version))
+		})
+	}
+}
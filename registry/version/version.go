@@ -0,0 +1,283 @@
+// Package version resolves the version specs of the packages, like ranges and latest:N, against the
+// versions published in a source registry.
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// All is the spec of all the published versions. AllKeyword is the same spec, easier to read next
+// to a package name pattern like "@acme/*".
+const (
+	All        = "*"
+	AllKeyword = "all"
+)
+
+// latestPrefix starts the spec of the N most recent versions, like latest:5.
+const latestPrefix = "latest:"
+
+// The first characters of a range, like >=4.17.0 <5, ^1.2 or ~1.2.3.
+const rangeOperators = "<>=~^!"
+
+// Dependency is a package required by a package version. Its version is either a literal version or
+// a constraint, like ^1.2 or >=1.0, <2, resolved to the most recent matching version of the source.
+type Dependency struct {
+	Name, Version, Constraint string
+}
+
+// IsSpec returns true when the version is a spec to resolve and not a literal version.
+func IsSpec(version string) bool {
+	if len(version) == 0 {
+		return false
+	}
+
+	return version == All || version == AllKeyword || strings.HasPrefix(version, latestPrefix) || strings.ContainsAny(version[:1], rangeOperators)
+}
+
+// Scheme is the versioning scheme of a package format: how its versions are ordered and matched by
+// a constraint.
+type Scheme int
+
+const (
+	// Semantic versions, see https://semver.org. The constraints follow the syntax of
+	// https://github.com/Masterminds/semver#checking-version-constraints.
+	Semantic Scheme = iota
+	// Maven versions, ordered like https://maven.apache.org/pom.html#version-order-specification.
+	// The constraints are comparisons, like >=1.0, <2.0, with || between alternatives.
+	Maven
+)
+
+// Resolve returns the published versions matching the spec, from the oldest to the newest. Except
+// for All, the prereleases and the published versions that the scheme can't read are ignored. An
+// error is returned when none of the published versions can be read.
+func Resolve(scheme Scheme, spec string, published []string) ([]string, error) {
+	if spec == All || spec == AllKeyword {
+		return scheme.sorted(published), nil
+	}
+
+	releases, err := scheme.releases(published)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version spec %q: %w", spec, err)
+	}
+
+	if strings.HasPrefix(spec, latestPrefix) {
+		n, err := strconv.Atoi(strings.TrimPrefix(spec, latestPrefix))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid version spec %q: the number of versions must be a positive integer", spec)
+		}
+		if n < len(releases) {
+			releases = releases[len(releases)-n:]
+		}
+		return releases, nil
+	}
+
+	matches, err := scheme.matcher(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version spec %q: %w", spec, err)
+	}
+
+	matching := []string{}
+	for _, v := range releases {
+		if matches(v) {
+			matching = append(matching, v)
+		}
+	}
+
+	return matching, nil
+}
+
+// Latest returns the most recent published version matching the constraint, like ^1.2 or >=1.0, <2.
+// For semantic versions, the prereleases only match a constraint with a prerelease. For Maven
+// versions, the prereleases are ignored. An empty string is returned when no version matches.
+func Latest(scheme Scheme, constraint string, published []string) (string, error) {
+	matches, err := scheme.matcher(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	candidates := published
+	if scheme == Maven {
+		candidates, _ = scheme.releases(published)
+	}
+
+	matching := []string{}
+	for _, v := range candidates {
+		if matches(v) {
+			matching = append(matching, v)
+		}
+	}
+
+	if len(matching) == 0 {
+		return "", nil
+	}
+	matching = scheme.sorted(matching)
+
+	return matching[len(matching)-1], nil
+}
+
+// FromInterval converts the interval notation of the Maven and NuGet version ranges, like [1.0,2.0)
+// or (,1.0], into a constraint. The Maven unions of intervals, like (,1.0],[1.2,), are converted into
+// alternatives. It returns false for a value that isn't an interval.
+func FromInterval(interval string) (string, bool) {
+	intervals := splitIntervals(interval)
+	if len(intervals) > 1 {
+		constraints := make([]string, 0, len(intervals))
+		for _, i := range intervals {
+			constraint, ok := fromInterval(i)
+			if !ok {
+				return "", false
+			}
+			constraints = append(constraints, constraint)
+		}
+		return strings.Join(constraints, " || "), true
+	}
+
+	return fromInterval(interval)
+}
+
+// splitIntervals splits a union of intervals on the commas between them.
+func splitIntervals(union string) []string {
+	intervals := []string{}
+	depth, start := 0, 0
+	for i, c := range union {
+		switch c {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				intervals = append(intervals, union[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(intervals, union[start:])
+}
+
+func fromInterval(interval string) (string, bool) {
+	interval = strings.TrimSpace(interval)
+	if len(interval) < 2 || !strings.ContainsAny(interval[:1], "[(") || !strings.ContainsAny(interval[len(interval)-1:], "])") {
+		return "", false
+	}
+
+	inner := interval[1 : len(interval)-1]
+	if !strings.Contains(inner, ",") {
+		if interval[0] != '[' || interval[len(interval)-1] != ']' {
+			return "", false
+		}
+		return "=" + strings.TrimSpace(inner), true
+	}
+
+	lower, upper, _ := strings.Cut(inner, ",")
+	lower, upper = strings.TrimSpace(lower), strings.TrimSpace(upper)
+	constraints := []string{}
+	if len(lower) != 0 {
+		operator := ">"
+		if interval[0] == '[' {
+			operator = ">="
+		}
+		constraints = append(constraints, operator+lower)
+	}
+	if len(upper) != 0 {
+		operator := "<"
+		if interval[len(interval)-1] == ']' {
+			operator = "<="
+		}
+		constraints = append(constraints, operator+upper)
+	}
+	if len(constraints) == 0 {
+		return All, true
+	}
+
+	return strings.Join(constraints, ", "), true
+}
+
+// releases returns the published versions that aren't prereleases, sorted. An error is returned
+// when no published version can be read.
+func (s Scheme) releases(published []string) ([]string, error) {
+	if s == Maven {
+		versions := []string{}
+		for _, p := range published {
+			if IsMavenRelease(p) {
+				versions = append(versions, p)
+			}
+		}
+		return s.sorted(versions), nil
+	}
+
+	versions := []*semver.Version{}
+	parsed := 0
+	for _, p := range published {
+		if v, err := semver.NewVersion(p); err == nil {
+			parsed++
+			if len(v.Prerelease()) == 0 {
+				versions = append(versions, v)
+			}
+		}
+	}
+	if parsed == 0 && len(published) != 0 {
+		return nil, fmt.Errorf("none of the %d published versions, like %q, is a semantic version", len(published), published[0])
+	}
+	sort.Sort(semver.Collection(versions))
+
+	return originals(versions), nil
+}
+
+// sorted sorts the versions. The ones that aren't semantic versions are put first, in lexical
+// order, except for Maven where all the versions can be compared.
+func (s Scheme) sorted(published []string) []string {
+	if s == Maven {
+		versions := append([]string{}, published...)
+		sort.SliceStable(versions, func(a, b int) bool {
+			return CompareMaven(versions[a], versions[b]) < 0
+		})
+		return versions
+	}
+
+	others := []string{}
+	versions := []*semver.Version{}
+	for _, p := range published {
+		if v, err := semver.NewVersion(p); err == nil {
+			versions = append(versions, v)
+		} else {
+			others = append(others, p)
+		}
+	}
+	sort.Strings(others)
+	sort.Sort(semver.Collection(versions))
+
+	return append(others, originals(versions)...)
+}
+
+// matcher parses the constraint and returns a function telling whether a version matches it.
+func (s Scheme) matcher(constraint string) (func(string) bool, error) {
+	if s == Maven {
+		return mavenMatcher(constraint)
+	}
+
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(version string) bool {
+		v, err := semver.NewVersion(version)
+		return err == nil && constraints.Check(v)
+	}, nil
+}
+
+func originals(versions []*semver.Version) []string {
+	result := make([]string, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, v.Original())
+	}
+
+	return result
+}
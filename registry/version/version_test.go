@@ -0,0 +1,246 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSpec(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "1.2.3", expected: false},
+		{version: "v1.2.3", expected: false},
+		{version: "1.2.3:pom", expected: false},
+		{version: "", expected: false},
+		{version: "*", expected: true},
+		{version: "all", expected: true},
+		{version: "latest:5", expected: true},
+		{version: ">=4.17.0 <5", expected: true},
+		{version: "^1.2", expected: true},
+		{version: "~1.2.3", expected: true},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.version, func(t *testing.T) {
+			require.Equal(t, spec.expected, IsSpec(spec.version))
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	published := []string{"5.0.0", "4.17.21", "4.16.0", "5.1.0-beta.1", "4.17.0", "3.10.1", "legacy"}
+
+	tests := []struct {
+		name          string
+		spec          string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "all versions",
+			spec:     "*",
+			expected: []string{"legacy", "3.10.1", "4.16.0", "4.17.0", "4.17.21", "5.0.0", "5.1.0-beta.1"},
+		},
+		{
+			name:     "all keyword",
+			spec:     "all",
+			expected: []string{"legacy", "3.10.1", "4.16.0", "4.17.0", "4.17.21", "5.0.0", "5.1.0-beta.1"},
+		},
+		{
+			name:     "latest versions",
+			spec:     "latest:2",
+			expected: []string{"4.17.21", "5.0.0"},
+		},
+		{
+			name:     "more latest versions than published",
+			spec:     "latest:10",
+			expected: []string{"3.10.1", "4.16.0", "4.17.0", "4.17.21", "5.0.0"},
+		},
+		{
+			name:     "range",
+			spec:     ">=4.17.0 <5",
+			expected: []string{"4.17.0", "4.17.21"},
+		},
+		{
+			name:     "caret range",
+			spec:     "^4.16",
+			expected: []string{"4.16.0", "4.17.0", "4.17.21"},
+		},
+		{
+			name:     "range without match",
+			spec:     ">=6",
+			expected: []string{},
+		},
+		{
+			name:          "invalid latest count",
+			spec:          "latest:none",
+			expectedError: `invalid version spec "latest:none": the number of versions must be a positive integer`,
+		},
+		{
+			name:          "invalid range",
+			spec:          ">=four",
+			expectedError: `invalid version spec ">=four": improper constraint: >=four`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			versions, err := Resolve(Semantic, spec.spec, published)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, versions)
+		})
+	}
+}
+
+func TestResolveWithoutSemanticVersions(t *testing.T) {
+	published := []string{"6.2.7.Final", "5.3.30.RELEASE"}
+
+	versions, err := Resolve(Semantic, "*", published)
+	require.NoError(t, err)
+	require.Equal(t, []string{"5.3.30.RELEASE", "6.2.7.Final"}, versions)
+
+	_, err = Resolve(Semantic, "latest:2", published)
+	require.EqualError(t, err, `invalid version spec "latest:2": none of the 2 published versions, like "6.2.7.Final", is a semantic version`)
+}
+
+func TestResolveMaven(t *testing.T) {
+	published := []string{"33.0.0-jre", "31.1-jre", "31.1-android", "32.0.0-rc1", "6.2.7.Final", "5.3.30.RELEASE", "33.1.0-SNAPSHOT", "2.0.0-M1"}
+
+	tests := []struct {
+		name          string
+		spec          string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "all versions",
+			spec:     "*",
+			expected: []string{"2.0.0-M1", "5.3.30.RELEASE", "6.2.7.Final", "31.1-android", "31.1-jre", "32.0.0-rc1", "33.0.0-jre", "33.1.0-SNAPSHOT"},
+		},
+		{
+			name:     "latest versions",
+			spec:     "latest:3",
+			expected: []string{"31.1-android", "31.1-jre", "33.0.0-jre"},
+		},
+		{
+			name:     "range",
+			spec:     ">=6, <32",
+			expected: []string{"6.2.7.Final", "31.1-android", "31.1-jre"},
+		},
+		{
+			name:     "alternatives",
+			spec:     "<6 || >=33",
+			expected: []string{"5.3.30.RELEASE", "33.0.0-jre"},
+		},
+		{
+			name:          "semantic range",
+			spec:          "^31.1",
+			expectedError: `invalid version spec "^31.1": improper Maven constraint: ^31.1`,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			versions, err := Resolve(Maven, spec.spec, published)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, versions)
+		})
+	}
+}
+
+func TestLatestMaven(t *testing.T) {
+	published := []string{"5.3.30.RELEASE", "6.0.0.RC1", "6.2.7.Final", "6.2.10.Final", "7.0.0-SNAPSHOT"}
+
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{constraint: "*", expected: "6.2.10.Final"},
+		{constraint: ">=5.0, <6.0", expected: "5.3.30.RELEASE"},
+		{constraint: "=6.2.7", expected: "6.2.7.Final"},
+		{constraint: ">=7", expected: ""},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.constraint, func(t *testing.T) {
+			latest, err := Latest(Maven, spec.constraint, published)
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, latest)
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	published := []string{"1.2.0", "1.10.0", "2.0.0", "2.1.0-beta.1", "legacy"}
+
+	tests := []struct {
+		constraint    string
+		expected      string
+		expectedError string
+	}{
+		{constraint: "*", expected: "2.0.0"},
+		{constraint: "^1.2", expected: "1.10.0"},
+		{constraint: ">=1.0, <1.5", expected: "1.2.0"},
+		{constraint: ">=2.1.0-0", expected: "2.1.0-beta.1"},
+		{constraint: ">=3", expected: ""},
+		{constraint: ">=three", expectedError: `invalid version constraint ">=three": improper constraint: >=three`},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.constraint, func(t *testing.T) {
+			latest, err := Latest(Semantic, spec.constraint, published)
+
+			if len(spec.expectedError) != 0 {
+				require.EqualError(t, err, spec.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, spec.expected, latest)
+		})
+	}
+}
+
+func TestFromInterval(t *testing.T) {
+	tests := []struct {
+		interval   string
+		expected   string
+		isInterval bool
+	}{
+		{interval: "[1.0,2.0)", expected: ">=1.0, <2.0", isInterval: true},
+		{interval: "(1.0, ]", expected: ">1.0", isInterval: true},
+		{interval: "(,1.0]", expected: "<=1.0", isInterval: true},
+		{interval: "[1.2.3]", expected: "=1.2.3", isInterval: true},
+		{interval: "[,]", expected: "*", isInterval: true},
+		{interval: "(,1.0],[1.2,)", expected: "<=1.0 || >=1.2", isInterval: true},
+		{interval: "[1.0,2.0), [3.0]", expected: ">=1.0, <2.0 || =3.0", isInterval: true},
+		{interval: "[1.0,2.0),3.0", isInterval: false},
+		{interval: "1.0", isInterval: false},
+		{interval: "(1.0)", isInterval: false},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.interval, func(t *testing.T) {
+			constraint, ok := FromInterval(spec.interval)
+
+			require.Equal(t, spec.isInterval, ok)
+			require.Equal(t, spec.expected, constraint)
+		})
+	}
+}
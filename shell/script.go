@@ -0,0 +1,134 @@
+// Package shell renders the imports into a POSIX shell script that copies the packages from the
+// host it runs on.
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/khulnasoft/packages-registry/util"
+)
+
+const defaultFileName = "import_packages.sh"
+
+var invalidNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// header starts the script. run executes the function of an import for a package version in a
+// subshell, from an empty directory, with the package variables exported, so nothing leaks into the
+// next package. The output of a failing package is printed and the package is listed in the summary.
+const header = `#!/bin/sh
+# Generated by pkgs_importer. The tools used by the imports, like npm or curl, must be installed.
+
+imported=0
+failed=0
+failures=""
+logs_dir=$(mktemp -d)
+
+# run IMPORT FUNCTION PACKAGE_NAME PACKAGE_VERSION [VARIABLE=VALUE...]
+run() {
+	import=$1
+	import_function=$2
+	name=$3
+	version=$4
+	shift 4
+
+	log="$logs_dir/$((imported + failed)).log"
+	work_dir=$(mktemp -d)
+	(
+		export PACKAGE_NAME="$name" PACKAGE_VERSION="$version"
+		for variable in "$@"; do
+			export "$variable"
+		done
+		cd "$work_dir" || exit 1
+		"$import_function"
+	) >"$log" 2>&1
+	status=$?
+	rm -rf "$work_dir"
+
+	if [ "$status" -eq 0 ]; then
+		imported=$((imported + 1))
+		echo "Imported $name $version of import $import"
+	else
+		failed=$((failed + 1))
+		failures="$failures
+- $name $version of import $import"
+		echo "Failed to import $name $version of import $import:" >&2
+		cat "$log" >&2
+	fi
+}
+`
+
+const footer = `
+echo "$imported packages imported and $failed failed"
+rm -rf "$logs_dir"
+
+if [ "$failed" -ne 0 ]; then
+	echo "Failed packages:$failures" >&2
+	exit 1
+fi
+`
+
+// Backend renders the imports into a shell script: a function per import with its scripts, and a
+// call of the function per package version. The images of the imports are not used.
+type Backend struct{}
+
+// Render returns the shell script of the imports.
+func (b Backend) Render(imports []pipeline.Import) ([]byte, error) {
+	script := strings.Builder{}
+	script.WriteString(header)
+
+	functions := make(map[string]string, len(imports))
+	for _, i := range imports {
+		function := functionName(i.Name)
+		if other, ok := functions[function]; ok {
+			return nil, fmt.Errorf("import %q has the same function name %q as the import %q", i.Name, function, other)
+		}
+		functions[function] = i.Name
+
+		// The scripts are not indented: they can have here-documents.
+		script.WriteString(fmt.Sprintf("\n# Import %s, run in the %s image in a pipeline.\n", i.Name, i.Image))
+		script.WriteString(fmt.Sprintf("%s() {\nset -e\n%s\n}\n", function, strings.Join(i.Scripts, "\n")))
+	}
+
+	for _, i := range imports {
+		if len(i.Packages) == 0 {
+			continue
+		}
+
+		script.WriteString("\n")
+		for _, p := range i.Packages {
+			arguments := []string{quote(i.Name), functionName(i.Name), quote(p.Name), quote(p.Version)}
+			for _, key := range util.OrderedMapKeysOf(p.Variables) {
+				arguments = append(arguments, quote(key+"="+p.Variables[key]))
+			}
+			script.WriteString(fmt.Sprintf("run %s\n", strings.Join(arguments, " ")))
+		}
+	}
+
+	script.WriteString(footer)
+
+	return []byte(script.String()), nil
+}
+
+// MaxSize returns 0, the size of a script is not limited.
+func (b Backend) MaxSize() int64 {
+	return 0
+}
+
+// DefaultFileName returns import_packages.sh.
+func (b Backend) DefaultFileName() string {
+	return defaultFileName
+}
+
+// functionName returns the name of the function of an import: import_ followed by the import name,
+// where the characters that a name can't have are replaced by _.
+func functionName(importName string) string {
+	return "import_" + invalidNameRegexp.ReplaceAllString(importName, "_")
+}
+
+// quote puts a value between single quotes, which keep every character but a single quote.
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name            string
+		imports         []pipeline.Import
+		expectedOutputs []string
+		fails           bool
+	}{
+		{
+			name: "with all packages imported",
+			imports: []pipeline.Import{
+				{
+					Name:    "import-1",
+					Scripts: []string{`echo "$PACKAGE_NAME $PACKAGE_VERSION $PACKAGE_FILE" > file`, "cat file"},
+					Packages: []pipeline.Package{
+						{Name: "it's", Version: "1.0.0", Variables: map[string]string{"PACKAGE_FILE": "a file.tgz"}},
+						{Name: "package2", Version: "2.0.0"},
+					},
+				},
+			},
+			expectedOutputs: []string{
+				"Imported it's 1.0.0 of import import-1",
+				"Imported package2 2.0.0 of import import-1",
+				"2 packages imported and 0 failed",
+			},
+		},
+		{
+			name: "with a failing package",
+			imports: []pipeline.Import{
+				{
+					Name:    "import1",
+					Scripts: []string{`echo "copying $PACKAGE_NAME"`, `test "$PACKAGE_NAME" != failing`, "echo copied"},
+					Packages: []pipeline.Package{
+						{Name: "failing", Version: "1.0.0"},
+						{Name: "package2", Version: "2.0.0"},
+					},
+				},
+				{
+					Name:    "import2",
+					Scripts: []string{"cat <<'EOF'\n$PACKAGE_NAME\nEOF"},
+				},
+			},
+			expectedOutputs: []string{
+				"Failed to import failing 1.0.0 of import import1:\ncopying failing\nImported package2 2.0.0 of import import1",
+				"1 packages imported and 1 failed",
+				"Failed packages:\n- failing 1.0.0 of import import1",
+			},
+			fails: true,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			content, err := Backend{}.Render(spec.imports)
+			require.Nil(t, err)
+
+			path := filepath.Join(t.TempDir(), defaultFileName)
+			require.Nil(t, os.WriteFile(path, content, 0o600))
+
+			output, err := exec.Command("sh", path).CombinedOutput()
+			if spec.fails {
+				require.Error(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+
+			for _, expectedOutput := range spec.expectedOutputs {
+				require.Contains(t, string(output), expectedOutput)
+			}
+		})
+	}
+}
+
+func TestRenderSameFunctionName(t *testing.T) {
+	_, err := Backend{}.Render([]pipeline.Import{{Name: "import-1"}, {Name: "import_1"}})
+
+	require.EqualError(t, err, `import "import_1" has the same function name "import_import_1" as the import "import-1"`)
+}
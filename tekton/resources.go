@@ -0,0 +1,221 @@
+// Package tekton renders the imports into Tekton Task and Pipeline resources.
+package tekton
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/khulnasoft/packages-registry/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultFileName = "import_packages.yaml"
+	apiVersion      = "tekton.dev/v1"
+	pipelineName    = "import-packages"
+	// The Kubernetes secret with the environment variables that the scripts use but don't set.
+	secretName = "pkgs-importer"
+	// A pipeline task name is a DNS label of 63 characters at most. It's the import name followed by
+	// - and the number of the package version, up to 6 digits.
+	maxImportNameLength = 63 - 7
+	// The resources are stored in etcd, that rejects requests over 1.5 MiB by default. The Pipeline
+	// resource, with a task per package version, is the largest one of the file.
+	maxSize int64 = 1536 * 1024
+)
+
+var invalidNameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Backend renders the imports into a Task per import, with a step running the scripts in the image
+// of the import, and a Pipeline with a task per package version. The environment variables that
+// the scripts use but don't set, like $DESTINATION_TOKEN in a token, are read from the keys of the
+// pkgs-importer secret.
+type Backend struct{}
+
+type metadata struct {
+	Name string `yaml:"name"`
+}
+
+type task struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   metadata `yaml:"metadata"`
+	Spec       taskSpec `yaml:"spec"`
+}
+
+type taskSpec struct {
+	Params []param `yaml:"params"`
+	Steps  []step  `yaml:"steps"`
+}
+
+type param struct {
+	Name    string  `yaml:"name"`
+	Type    string  `yaml:"type"`
+	Default *string `yaml:"default,omitempty"`
+}
+
+type step struct {
+	Name   string   `yaml:"name"`
+	Image  string   `yaml:"image"`
+	Env    []envVar `yaml:"env"`
+	Script string   `yaml:"script"`
+}
+
+type envVar struct {
+	Name      string     `yaml:"name"`
+	Value     string     `yaml:"value,omitempty"`
+	ValueFrom *valueFrom `yaml:"valueFrom,omitempty"`
+}
+
+type valueFrom struct {
+	SecretKeyRef secretKeyRef `yaml:"secretKeyRef"`
+}
+
+type secretKeyRef struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type tektonPipeline struct {
+	APIVersion string       `yaml:"apiVersion"`
+	Kind       string       `yaml:"kind"`
+	Metadata   metadata     `yaml:"metadata"`
+	Spec       pipelineSpec `yaml:"spec"`
+}
+
+type pipelineSpec struct {
+	Tasks []pipelineTask `yaml:"tasks"`
+}
+
+type pipelineTask struct {
+	Name    string       `yaml:"name"`
+	TaskRef metadata     `yaml:"taskRef"`
+	Params  []paramValue `yaml:"params"`
+}
+
+type paramValue struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// Render returns the Task resources of the imports followed by the Pipeline resource, in a multi
+// document YAML file. The imports without packages don't get a Task.
+func (b Backend) Render(imports []pipeline.Import) ([]byte, error) {
+	buff := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buff)
+	p := tektonPipeline{
+		APIVersion: apiVersion,
+		Kind:       "Pipeline",
+		Metadata:   metadata{Name: pipelineName},
+	}
+	names := map[string]string{}
+
+	for _, i := range imports {
+		if len(i.Packages) == 0 {
+			continue
+		}
+
+		name := resourceName(i.Name)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("import %q has the same resource name %q as the import %q", i.Name, name, other)
+		}
+		names[name] = i.Name
+
+		t := newTask(name, i)
+		if err := encoder.Encode(t); err != nil {
+			return nil, err
+		}
+
+		for n, pkg := range i.Packages {
+			p.Spec.Tasks = append(p.Spec.Tasks, newPipelineTask(name, t.Metadata.Name, n+1, pkg))
+		}
+	}
+
+	if err := encoder.Encode(p); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+// MaxSize returns the size limit of an etcd request, 1.5 MiB.
+func (b Backend) MaxSize() int64 {
+	return maxSize
+}
+
+// DefaultFileName returns import_packages.yaml.
+func (b Backend) DefaultFileName() string {
+	return defaultFileName
+}
+
+// newTask returns the Task of an import. PACKAGE_NAME and PACKAGE_VERSION are required parameters,
+// the other variables of the packages are optional.
+func newTask(name string, i pipeline.Import) task {
+	empty := ""
+	params := []param{}
+	env := []envVar{}
+	for _, variable := range i.VariableNames() {
+		p := param{Name: variable, Type: "string"}
+		if variable != "PACKAGE_NAME" && variable != "PACKAGE_VERSION" {
+			p.Default = &empty
+		}
+		params = append(params, p)
+		env = append(env, envVar{Name: variable, Value: fmt.Sprintf("$(params.%s)", variable)})
+	}
+	for _, secret := range i.Secrets() {
+		env = append(env, envVar{
+			Name:      secret,
+			ValueFrom: &valueFrom{SecretKeyRef: secretKeyRef{Name: secretName, Key: secret}},
+		})
+	}
+
+	return task{
+		APIVersion: apiVersion,
+		Kind:       "Task",
+		Metadata:   metadata{Name: pipelineName + "-" + name},
+		Spec: taskSpec{
+			Params: params,
+			Steps: []step{
+				{
+					Name:   "import",
+					Image:  i.Image,
+					Env:    env,
+					Script: "#!/bin/sh\nset -e\n" + strings.Join(i.Scripts, "\n"),
+				},
+			},
+		},
+	}
+}
+
+func newPipelineTask(name, taskName string, n int, p pipeline.Package) pipelineTask {
+	variables := p.AllVariables()
+	params := make([]paramValue, 0, len(variables))
+	for _, key := range util.OrderedMapKeysOf(variables) {
+		params = append(params, paramValue{Name: key, Value: variables[key]})
+	}
+
+	return pipelineTask{
+		Name:    fmt.Sprintf("%s-%d", name, n),
+		TaskRef: metadata{Name: taskName},
+		Params:  params,
+	}
+}
+
+// resourceName returns the import name in lower case, where the characters that a Kubernetes
+// resource name can't have are replaced by -, truncated to maxImportNameLength.
+func resourceName(importName string) string {
+	name := strings.Trim(invalidNameRegexp.ReplaceAllString(strings.ToLower(importName), "-"), "-")
+	if len(name) > maxImportNameLength {
+		name = strings.TrimRight(name[:maxImportNameLength], "-")
+	}
+	if len(name) == 0 {
+		return "import"
+	}
+
+	return name
+}
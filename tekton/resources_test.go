@@ -0,0 +1,101 @@
+package tekton
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/packages-registry/pipeline"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRender(t *testing.T) {
+	imports := []pipeline.Import{
+		{
+			Name:    "My_Import." + strings.Repeat("a", 60),
+			Image:   "alpine",
+			Scripts: []string{`curl -u "user:$SOURCE_TOKEN" https://source.test/$PACKAGE_FILE`},
+			Packages: []pipeline.Package{
+				{Name: "package1", Version: "1.0.0", Variables: map[string]string{"PACKAGE_FILE": "package1.tgz"}},
+				{Name: "package2", Version: "2.0.0"},
+			},
+		},
+		{Name: "import2", Image: "alpine"},
+	}
+
+	content, err := Backend{}.Render(imports)
+	require.Nil(t, err)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	tk := task{}
+	require.Nil(t, decoder.Decode(&tk))
+	p := tektonPipeline{}
+	require.Nil(t, decoder.Decode(&p))
+	require.True(t, errors.Is(decoder.Decode(&struct{}{}), io.EOF))
+
+	name := "my-import-" + strings.Repeat("a", maxImportNameLength-len("my-import-"))
+	empty := ""
+	require.Equal(t, "import-packages-"+name, tk.Metadata.Name)
+	require.Equal(t, []param{
+		{Name: "PACKAGE_FILE", Type: "string", Default: &empty},
+		{Name: "PACKAGE_NAME", Type: "string"},
+		{Name: "PACKAGE_VERSION", Type: "string"},
+	}, tk.Spec.Params)
+	require.Equal(t, envVar{
+		Name:      "SOURCE_TOKEN",
+		ValueFrom: &valueFrom{SecretKeyRef: secretKeyRef{Name: secretName, Key: "SOURCE_TOKEN"}},
+	}, tk.Spec.Steps[0].Env[3])
+
+	require.Len(t, p.Spec.Tasks, 2)
+	require.Equal(t, name+"-2", p.Spec.Tasks[1].Name)
+	require.LessOrEqual(t, len(p.Spec.Tasks[1].Name), 63)
+	require.Equal(t, tk.Metadata.Name, p.Spec.Tasks[1].TaskRef.Name)
+	require.Equal(t, []paramValue{{Name: "PACKAGE_NAME", Value: "package2"}, {Name: "PACKAGE_VERSION", Value: "2.0.0"}}, p.Spec.Tasks[1].Params)
+}
+
+func TestRenderSameResourceName(t *testing.T) {
+	packages := []pipeline.Package{{Name: "package1", Version: "1.0.0"}}
+	_, err := Backend{}.Render([]pipeline.Import{{Name: "import-1", Packages: packages}, {Name: "Import_1", Packages: packages}})
+
+	require.EqualError(t, err, `import "Import_1" has the same resource name "import-1" as the import "import-1"`)
+}
+
+func TestRenderNearTheLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		packagesCount int
+		overTheLimit  bool
+	}{
+		{
+			name:          "under the limit",
+			packagesCount: 6500,
+		},
+		{
+			name:          "over the limit",
+			packagesCount: 7000,
+			overTheLimit:  true,
+		},
+	}
+
+	for _, spec := range tests {
+		t.Run(spec.name, func(t *testing.T) {
+			packages := make([]pipeline.Package, 0, spec.packagesCount)
+			for n := 0; n < spec.packagesCount; n++ {
+				packages = append(packages, pipeline.Package{Name: fmt.Sprintf("package%d", n), Version: "1.0.0"})
+			}
+
+			content, err := Backend{}.Render([]pipeline.Import{{Name: "import1", Image: "alpine", Packages: packages}})
+			require.Nil(t, err)
+
+			if spec.overTheLimit {
+				require.GreaterOrEqual(t, int64(len(content)), Backend{}.MaxSize())
+			} else {
+				require.Less(t, int64(len(content)), Backend{}.MaxSize())
+			}
+		})
+	}
+}